@@ -304,3 +304,49 @@ func Test2Q_Peek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+// Test that a one-off scan doesn't evict frequently used entries
+func Test2Q_ScanResistance(t *testing.T) {
+	l, err := New2Q(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promote the working set into the frequent queue
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		l.Get(i)
+	}
+
+	// Scan many keys once each
+	for i := 100; i < 200; i++ {
+		l.Add(i, i)
+	}
+
+	for i := 0; i < 4; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("hot key %d should have survived the scan", i)
+		}
+	}
+	if l.Len() != 8 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	// By contrast a plain LRU loses the whole working set
+	lru, err := New(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		lru.Add(i, i)
+		lru.Get(i)
+	}
+	for i := 100; i < 200; i++ {
+		lru.Add(i, i)
+	}
+	for i := 0; i < 4; i++ {
+		if lru.Contains(i) {
+			t.Fatalf("LRU should not have kept hot key %d", i)
+		}
+	}
+}
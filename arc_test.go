@@ -375,3 +375,54 @@ func TestARC_Peek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+// Test that p adapts as the workload alternates between scans and reuse
+func TestARC_AlternatingWorkloads(t *testing.T) {
+	l, err := NewARC(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reuse-heavy: move a working set into t2
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		l.Get(i)
+	}
+
+	// Scan-heavy: one-off keys churn through t1 without touching t2
+	for i := 100; i < 120; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 4; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("hot key %d should have survived the scan", i)
+		}
+	}
+	if l.p != 0 {
+		t.Fatalf("bad: %d", l.p)
+	}
+
+	// Re-adding keys just evicted from t1 hits b1, growing the recent side
+	for i := 112; i < 116; i++ {
+		l.Add(i, i)
+	}
+	grown := l.p
+	if grown == 0 {
+		t.Fatalf("p should have grown after b1 hits")
+	}
+
+	// New keys now push the older frequent entries out to b2, and
+	// re-adding those hits b2, shrinking the recent side again
+	for i := 200; i < 210; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	if l.p >= grown {
+		t.Fatalf("p should have shrunk after b2 hits: %d >= %d", l.p, grown)
+	}
+	if n := l.t1.Len() + l.t2.Len(); n != 8 {
+		t.Fatalf("bad: %d", n)
+	}
+}
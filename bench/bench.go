@@ -0,0 +1,154 @@
+// Package bench replays synthetic or recorded access traces against a cache
+// so that eviction policies can be compared on the same workload.
+//
+// A trace is produced by a WorkloadGenerator. The built-in generators draw
+// keys from a Zipfian, uniform or sequential distribution; a recorded trace
+// can be replayed by implementing WorkloadGenerator over it.
+package bench
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// WorkloadGenerator produces the keys of an access trace, one per call to
+// Next. Generators are not safe for concurrent use.
+type WorkloadGenerator interface {
+	Next() interface{}
+}
+
+// CacheIface is the subset of cache methods RunPolicy needs. It is satisfied
+// by lru.Cache, lru.ShardedCache, simplelru.LRU and simplelru.LFU; caches
+// whose Add reports nothing, such as lru.TwoQueueCache and lru.ARCCache,
+// can be wrapped with Adapt.
+type CacheIface interface {
+	Get(key interface{}) (value interface{}, ok bool)
+	Add(key, value interface{}) (evicted bool)
+	Len() int
+}
+
+// Result holds the counters of a RunPolicy run.
+type Result struct {
+	Hits      uint64 // Lookups that found the key
+	Misses    uint64 // Lookups that did not, each followed by an Add
+	Evictions uint64 // Adds that evicted an entry
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 if there
+// were no lookups.
+func (r Result) HitRatio() float64 {
+	total := r.Hits + r.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(total)
+}
+
+// RunPolicy replays ops keys from gen against cache. Each key is looked up
+// with Get and, on a miss, added with itself as the value, the way a
+// read-through cache would be filled.
+func RunPolicy(cache CacheIface, gen WorkloadGenerator, ops int) Result {
+	var res Result
+	for i := 0; i < ops; i++ {
+		key := gen.Next()
+		if _, ok := cache.Get(key); ok {
+			res.Hits++
+			continue
+		}
+		res.Misses++
+		if cache.Add(key, key) {
+			res.Evictions++
+		}
+	}
+	return res
+}
+
+// plainCache is a cache whose Add does not report evictions.
+type plainCache interface {
+	Get(key interface{}) (value interface{}, ok bool)
+	Add(key, value interface{})
+	Len() int
+}
+
+// Adapt wraps a cache whose Add does not report evictions so that it
+// satisfies CacheIface. An Add is taken to have evicted when it did not grow
+// the cache, which holds for the new keys RunPolicy adds.
+func Adapt(c plainCache) CacheIface {
+	return adapted{c}
+}
+
+type adapted struct {
+	plainCache
+}
+
+func (a adapted) Add(key, value interface{}) (evicted bool) {
+	before := a.Len()
+	a.plainCache.Add(key, value)
+	return a.Len() <= before
+}
+
+// Zipf generates int keys in [0, n) where key k is drawn with probability
+// proportional to 1/(k+1)^s, so that a few keys take most of the accesses.
+type Zipf struct {
+	zipf *rand.Zipf
+}
+
+// NewZipf creates a Zipf generator over n keys with exponent s, which must
+// be greater than 1, seeded with seed.
+func NewZipf(seed int64, s float64, n int) (*Zipf, error) {
+	if s <= 1 {
+		return nil, errors.New("Must provide a Zipf exponent greater than 1")
+	}
+	if n <= 0 {
+		return nil, errors.New("Must provide a positive key count")
+	}
+	r := rand.New(rand.NewSource(seed))
+	return &Zipf{zipf: rand.NewZipf(r, s, 1, uint64(n-1))}, nil
+}
+
+// Next returns the next key.
+func (z *Zipf) Next() interface{} {
+	return int(z.zipf.Uint64())
+}
+
+// Uniform generates int keys drawn uniformly from [0, n).
+type Uniform struct {
+	rand *rand.Rand
+	n    int
+}
+
+// NewUniform creates a Uniform generator over n keys seeded with seed.
+func NewUniform(seed int64, n int) (*Uniform, error) {
+	if n <= 0 {
+		return nil, errors.New("Must provide a positive key count")
+	}
+	return &Uniform{rand: rand.New(rand.NewSource(seed)), n: n}, nil
+}
+
+// Next returns the next key.
+func (u *Uniform) Next() interface{} {
+	return u.rand.Intn(u.n)
+}
+
+// Sequential generates the int keys 0 through n-1 in order, then starts
+// over. A loop over more keys than the cache holds is the worst case for
+// LRU, which then misses on every access.
+type Sequential struct {
+	next int
+	n    int
+}
+
+// NewSequential creates a Sequential generator over n keys.
+func NewSequential(n int) (*Sequential, error) {
+	if n <= 0 {
+		return nil, errors.New("Must provide a positive key count")
+	}
+	return &Sequential{n: n}, nil
+}
+
+// Next returns the next key.
+func (s *Sequential) Next() interface{} {
+	key := s.next
+	s.next = (s.next + 1) % s.n
+	return key
+}
@@ -0,0 +1,143 @@
+package bench
+
+import (
+	"math"
+	"testing"
+
+	lru "github.com/rubrikinc/golang-lru"
+	"github.com/rubrikinc/golang-lru/simplelru"
+)
+
+func histogram(t *testing.T, gen WorkloadGenerator, n, samples int) []int {
+	counts := make([]int, n)
+	for i := 0; i < samples; i++ {
+		key := gen.Next().(int)
+		if key < 0 || key >= n {
+			t.Fatalf("key out of range: %d", key)
+		}
+		counts[key]++
+	}
+	return counts
+}
+
+func TestUniform(t *testing.T) {
+	n, samples := 100, 200000
+	gen, err := NewUniform(1, n)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := float64(samples) / float64(n)
+	for k, c := range histogram(t, gen, n, samples) {
+		if math.Abs(float64(c)-want) > 0.1*want {
+			t.Fatalf("key %d drawn %d times, want about %v", k, c, want)
+		}
+	}
+
+	if _, err := NewUniform(1, 0); err == nil {
+		t.Fatalf("should reject an empty key space")
+	}
+}
+
+func TestZipf(t *testing.T) {
+	n, samples, s := 1000, 200000, 1.5
+	gen, err := NewZipf(1, s, n)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	counts := histogram(t, gen, n, samples)
+
+	// Frequency falls off as a power of the rank
+	for k := 1; k < 4; k++ {
+		want := math.Pow(float64(k+1)/float64(k), s)
+		got := float64(counts[k-1]) / float64(counts[k])
+		if math.Abs(got-want) > 0.1*want {
+			t.Fatalf("bad ratio of key %d to key %d: %v, want about %v", k-1, k, got, want)
+		}
+	}
+
+	// The ten most popular keys take most of the accesses
+	top := 0
+	for _, c := range counts[:10] {
+		top += c
+	}
+	if top < samples/2 {
+		t.Fatalf("top keys drawn only %d of %d times", top, samples)
+	}
+
+	if _, err := NewZipf(1, 1, n); err == nil {
+		t.Fatalf("should reject an exponent of 1")
+	}
+}
+
+func TestSequential(t *testing.T) {
+	gen, err := NewSequential(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		if key := gen.Next(); key != i%3 {
+			t.Fatalf("bad key at %d: %v", i, key)
+		}
+	}
+}
+
+func TestRunPolicy(t *testing.T) {
+	// A loop that fits keeps hitting after the first pass
+	l, err := simplelru.NewLRUWithEvict(10, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gen, _ := NewSequential(10)
+	res := RunPolicy(l, gen, 100)
+	if res.Hits != 90 || res.Misses != 10 || res.Evictions != 0 {
+		t.Fatalf("bad result: %+v", res)
+	}
+	if res.HitRatio() != 0.9 {
+		t.Fatalf("bad hit ratio: %v", res.HitRatio())
+	}
+
+	// A loop that does not fit never hits under LRU
+	l.Purge()
+	gen, _ = NewSequential(11)
+	res = RunPolicy(l, gen, 110)
+	if res.Hits != 0 || res.Evictions != 100 {
+		t.Fatalf("bad result: %+v", res)
+	}
+}
+
+// Test that the same trace can be replayed against different policies
+func TestRunPolicy_Compare(t *testing.T) {
+	caches := map[string]func() CacheIface{
+		"lru": func() CacheIface {
+			c, _ := lru.New(100)
+			return c
+		},
+		"lfu": func() CacheIface {
+			c, _ := simplelru.NewLFUWithEvict(100, nil)
+			return c
+		},
+		"2q": func() CacheIface {
+			c, _ := lru.New2Q(100)
+			return Adapt(c)
+		},
+		"arc": func() CacheIface {
+			c, _ := lru.NewARC(100)
+			return Adapt(c)
+		},
+	}
+	for name, newCache := range caches {
+		gen, _ := NewZipf(1, 1.2, 10000)
+		res := RunPolicy(newCache(), gen, 20000)
+		if res.Hits+res.Misses != 20000 {
+			t.Fatalf("%s: bad result: %+v", name, res)
+		}
+		// Skew lets every policy do better than the 1% a uniform trace
+		// would give
+		if res.HitRatio() < 0.2 {
+			t.Fatalf("%s: bad hit ratio: %v", name, res.HitRatio())
+		}
+		if res.Evictions != res.Misses-100 {
+			t.Fatalf("%s: bad evictions: %+v", name, res)
+		}
+	}
+}
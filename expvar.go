@@ -0,0 +1,21 @@
+package lru
+
+import "expvar"
+
+// PublishExpvar publishes the statistics of c under name in the expvar
+// registry. Each read reports the current hits, misses, evictions, len and
+// cap of the cache. Like expvar.Publish, it panics if name is already in use.
+func PublishExpvar(name string, c *Cache) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		stats := c.lru.Stats()
+		return map[string]interface{}{
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"evictions": stats.Evictions,
+			"len":       c.lru.Len(),
+			"cap":       c.lru.Cap(),
+		}
+	}))
+}
@@ -0,0 +1,36 @@
+package lru
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	PublishExpvar("lru_test_cache", l)
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(3)
+	l.Get(1)
+
+	v := expvar.Get("lru_test_cache")
+	if v == nil {
+		t.Fatalf("cache stats should be published")
+	}
+	var got map[string]int
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := map[string]int{"hits": 1, "misses": 1, "evictions": 1, "len": 2, "cap": 2}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("bad %s: %v != %v", k, got[k], n)
+		}
+	}
+}
@@ -1,15 +1,23 @@
 package lru
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/rubrikinc/golang-lru/simplelru"
 )
 
 // Cache is a thread-safe fixed size LRU cache.
 type Cache struct {
-	lru  simplelru.LRUCache
+	lru  *simplelru.LRU
 	lock sync.RWMutex
+
+	loads flightGroup
+
+	janitorLock sync.Mutex
+	janitorStop chan struct{}
+	janitorDone chan struct{}
 }
 
 // New creates an LRU of the given size.
@@ -17,6 +25,21 @@ func New(size int) (*Cache, error) {
 	return NewWithEvict(size, nil)
 }
 
+// NewCache creates an LRU of the given size. It mirrors
+// simplelru.NewLRUWithEvict without an eviction callback.
+func NewCache(size int) (*Cache, error) {
+	return New(size)
+}
+
+// NewCacheWithEvict constructs a fixed size cache with the given eviction
+// callback. It mirrors simplelru.NewLRUWithEvict.
+func NewCacheWithEvict(
+	size int,
+	onEvicted func(key interface{}, value interface{}),
+) (*Cache, error) {
+	return NewWithEvict(size, onEvicted)
+}
+
 // NewWithAcquireAndEvict constructs a fixed size cache with the given eviction
 // and acquire callbacks.
 func NewWithAcquireAndEvict(
@@ -47,6 +70,118 @@ func NewWithEvict(
 	return NewWithAcquireAndEvict(size, nil, onEvicted)
 }
 
+// NewWithOptions constructs a fixed size cache configured by opts.
+func NewWithOptions(size int, opts ...simplelru.Option) (*Cache, error) {
+	lru, err := simplelru.NewLRUWithOptions(size, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		lru: lru,
+	}
+	return c, nil
+}
+
+// NewWithTTL constructs a fixed size cache whose entries added through Add
+// expire after defaultTTL, with the given eviction callback. Expired entries
+// are only reclaimed when accessed unless a janitor is started.
+func NewWithTTL(
+	size int,
+	defaultTTL time.Duration,
+	onEvicted func(key interface{}, value interface{}),
+) (*Cache, error) {
+	lru, err := simplelru.NewLRUWithTTL(
+		size,
+		defaultTTL,
+		simplelru.EvictCallback(onEvicted),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		lru: lru,
+	}
+	return c, nil
+}
+
+// NewWithLimits constructs a cache bounded both by the count of its entries
+// and by their total size as estimated by sizeOf, with the given eviction
+// callback.
+func NewWithLimits(
+	maxItems int,
+	maxBytes int64,
+	sizeOf func(key interface{}, value interface{}) int64,
+	onEvicted func(key interface{}, value interface{}),
+) (*Cache, error) {
+	lru, err := simplelru.NewLRUWithLimits(
+		maxItems,
+		maxBytes,
+		simplelru.SizeOfFunc(sizeOf),
+		simplelru.EvictCallback(onEvicted),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		lru: lru,
+	}
+	return c, nil
+}
+
+// StartJanitor starts a background goroutine that removes expired entries
+// every interval, firing the eviction callback for each. It does nothing if
+// interval is not positive or a janitor is already running.
+func (c *Cache) StartJanitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	c.janitorLock.Lock()
+	defer c.janitorLock.Unlock()
+	if c.janitorStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.janitorStop = stop
+	c.janitorDone = done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.lock.Lock()
+				c.lru.RemoveExpired()
+				c.lock.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor stops the janitor started by StartJanitor and waits for it to
+// exit. It does nothing if no janitor is running.
+func (c *Cache) StopJanitor() {
+	c.janitorLock.Lock()
+	defer c.janitorLock.Unlock()
+	if c.janitorStop == nil {
+		return
+	}
+	close(c.janitorStop)
+	<-c.janitorDone
+	c.janitorStop = nil
+	c.janitorDone = nil
+}
+
+// Close stops any running janitor. It is safe to call more than once.
+func (c *Cache) Close() error {
+	c.StopJanitor()
+	return nil
+}
+
 // Purge is used to completely clear the cache.
 func (c *Cache) Purge() {
 	c.lock.Lock()
@@ -57,6 +192,10 @@ func (c *Cache) Purge() {
 // GetOrAdd tries to lookup a key in the cache, returning the value.
 // Otherwise, add the key value pair, returning the value.
 // Along with if an eviction occurred and if value was added.
+// The lookup and the add happen under one write lock, so of many concurrent
+// calls for an absent key exactly one adds its value and the others return
+// it; a Get followed by an Add, as on a simplelru.LRU, gives no such
+// guarantee once shared between goroutines.
 func (c *Cache) GetOrAdd(
 	key interface{},
 	value interface{},
@@ -66,6 +205,95 @@ func (c *Cache) GetOrAdd(
 	return c.lru.GetOrAdd(key, value)
 }
 
+// GetOrAddWithCost is like GetOrAdd for a cache bounded by cost, adding
+// value on a miss with the given cost.
+func (c *Cache) GetOrAddWithCost(key, value interface{}, cost int64) (actual interface{}, added, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetOrAddWithCost(key, value, cost)
+}
+
+// GetOrAddWith tries to lookup a key in the cache, returning the value.
+// Otherwise it adds the value returned by factory, which is only called on a
+// miss, and returns it. factory runs with the cache locked, so it is called
+// at most once for concurrent misses on the same key.
+func (c *Cache) GetOrAddWith(key interface{}, factory func() interface{}) (value interface{}, added bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetOrAddWith(key, factory)
+}
+
+// GetOrAddEvicted is like GetOrAdd, additionally returning the entry that was
+// evicted to make room for value.
+func (c *Cache) GetOrAddEvicted(key, value interface{}) (
+	actual interface{},
+	added bool,
+	evictedKey, evictedValue interface{},
+	evicted bool,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetOrAddEvicted(key, value)
+}
+
+// GetOrLoad looks up a key's value from the cache, and on a miss calls
+// loader to compute it, caching the value unless loader returns an error. A
+// negative entry returns simplelru.ErrNegativeEntry without calling loader.
+// loader runs without the cache locked, and concurrent misses for keys with
+// the same normalized form share a single loader invocation and its result.
+// If loader panics, every caller sharing it panics too.
+func (c *Cache) GetOrLoad(
+	key interface{},
+	loader func(key interface{}) (interface{}, error),
+) (value interface{}, err error) {
+	if value, ok, err := c.cached(key); ok {
+		return value, err
+	}
+	return c.loads.Do(c.lru.NormalizeKey(key), func() (interface{}, error) {
+		// A flight that ended just after our miss may have cached the key
+		if value, ok, err := c.cached(key); ok {
+			return value, err
+		}
+		value, err := loader(key)
+		if err != nil {
+			return nil, err
+		}
+		c.Add(key, value)
+		return value, nil
+	})
+}
+
+// GetOrFetch looks up a key's value from the cache, and on a miss calls the
+// fetcher set by simplelru.WithFetcher, caching and returning the value it
+// fetched. Fetch errors are not cached unless simplelru.WithNegativeFetchTTL
+// is set. Like GetOrLoad, the fetch runs without the cache locked, and
+// concurrent misses for the same key share a single fetch and its result.
+// Each caller returns ctx.Err() as soon as its own ctx is done, while the
+// shared fetch goes on for the others; its context carries the values of
+// the first caller's ctx and is only cancelled once every caller has given
+// up.
+func (c *Cache) GetOrFetch(ctx context.Context, key interface{}) (value interface{}, err error) {
+	if value, ok, err := c.cached(key); ok {
+		return value, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.loads.DoContext(ctx, c.lru.NormalizeKey(key), func(ctx context.Context) (interface{}, error) {
+		if value, ok, err := c.cached(key); ok {
+			return value, err
+		}
+		value, err := c.lru.Fetch(ctx, key)
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if err := c.lru.AddFetched(ctx, key, value, err); err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+}
+
 // Add adds a value to the cache.  Returns true if an eviction occurred.
 func (c *Cache) Add(key, value interface{}) (evicted bool) {
 	c.lock.Lock()
@@ -73,6 +301,82 @@ func (c *Cache) Add(key, value interface{}) (evicted bool) {
 	return c.lru.Add(key, value)
 }
 
+// TryAdd adds a value to the cache like Add, additionally reporting whether
+// the value was stored, which is false only when a cache constructed with
+// simplelru.WithRejectOnFull is full and key is new.
+func (c *Cache) TryAdd(key, value interface{}) (inserted, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.TryAdd(key, value)
+}
+
+// BumpEpoch logically invalidates every entry currently in the cache, see
+// simplelru.LRU.BumpEpoch.
+func (c *Cache) BumpEpoch() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.BumpEpoch()
+}
+
+// AddEx adds a value to the cache like Add, additionally reporting whether
+// key is new rather than an overwrite of an existing entry.
+func (c *Cache) AddEx(key, value interface{}) (inserted, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddEx(key, value)
+}
+
+// AddReturningEvicted adds a value to the cache like Add, additionally
+// returning the entry evicted to make room for it.
+func (c *Cache) AddReturningEvicted(key, value interface{}) (evictedKey, evictedValue interface{}, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddReturningEvicted(key, value)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl.  Returns true
+// if an eviction occurred.
+func (c *Cache) AddWithTTL(key, value interface{}, ttl time.Duration) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddWithTTL(key, value, ttl)
+}
+
+// AddNegative caches the absence of a value for key for the given ttl.
+// Returns true if an eviction occurred.
+func (c *Cache) AddNegative(key interface{}, ttl time.Duration) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddNegative(key, ttl)
+}
+
+// GetWithTTL looks up a key's value from the cache like Get, additionally
+// returning the time remaining until the entry expires. An entry that never
+// expires is reported with a zero ttl.
+func (c *Cache) GetWithTTL(key interface{}) (value interface{}, ttl time.Duration, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetWithTTL(key)
+}
+
+// Lookup looks up a key's value from the cache like Get, additionally
+// reporting whether the key is cached as negative.
+func (c *Cache) Lookup(key interface{}) (value interface{}, ok, negative bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Lookup(key)
+}
+
+// cached looks up key like Lookup, returning simplelru.ErrNegativeEntry for
+// a negative entry.
+func (c *Cache) cached(key interface{}) (value interface{}, ok bool, err error) {
+	value, ok, negative := c.Lookup(key)
+	if negative {
+		return nil, true, simplelru.ErrNegativeEntry
+	}
+	return value, ok, nil
+}
+
 // Get looks up a key's value from the cache.
 func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
 	c.lock.Lock()
@@ -80,6 +384,85 @@ func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
 	return c.lru.Get(key)
 }
 
+// GetMulti looks up several keys under a single lock acquisition. Found
+// keys are promoted in the order they appear in keys.
+func (c *Cache) GetMulti(keys []interface{}) (found map[interface{}]interface{}, missing []interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetMulti(keys)
+}
+
+// AddMultiWithJitteredTTL adds several values under a single lock
+// acquisition, giving each a TTL drawn uniformly from baseTTL-jitter to
+// baseTTL+jitter. Returns how many of the adds caused an eviction.
+func (c *Cache) AddMultiWithJitteredTTL(
+	items map[interface{}]interface{},
+	baseTTL, jitter time.Duration,
+) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddMultiWithJitteredTTL(items, baseTTL, jitter)
+}
+
+// GetOrLoadMulti looks up several keys like GetMulti, then calls loader once
+// with the missing keys, caching and merging the values it returns. If loader
+// returns an error nothing is cached and the error is returned. loader runs
+// without the cache locked.
+func (c *Cache) GetOrLoadMulti(
+	keys []interface{},
+	loader func(missing []interface{}) (map[interface{}]interface{}, error),
+) (map[interface{}]interface{}, error) {
+	found, missing := c.GetMulti(keys)
+	if len(missing) == 0 {
+		return found, nil
+	}
+	loaded, err := loader(missing)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range missing {
+		if value, ok := loaded[key]; ok {
+			c.lru.Add(key, value)
+			found[key] = value
+		}
+	}
+	return found, nil
+}
+
+// AddMulti adds several values under a single lock acquisition, returning
+// how many of the adds caused an eviction.
+func (c *Cache) AddMulti(items map[interface{}]interface{}) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddMulti(items)
+}
+
+// Access reports whether key is in the cache and, if so, promotes it like
+// Get without returning its value.
+func (c *Cache) Access(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Access(key)
+}
+
+// Touch updates the "recently used"-ness of the key without returning its
+// value. Returns whether the key was present.
+func (c *Cache) Touch(key interface{}) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Touch(key)
+}
+
+// UpdateValue replaces the value of an existing key without updating its
+// "recently used"-ness. Returns whether the key was present.
+func (c *Cache) UpdateValue(key, value interface{}) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.UpdateValue(key, value)
+}
+
 // Contains checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *Cache) Contains(key interface{}) bool {
@@ -96,25 +479,135 @@ func (c *Cache) Peek(key interface{}) (value interface{}, ok bool) {
 	return c.lru.Peek(key)
 }
 
-// ContainsOrAdd checks if a key is in the cache  without updating the
-// recent-ness or deleting it for being stale,  and if not, adds the value.
-// Returns whether found and whether an eviction occurred.
+// ContainsOrExpire checks if a key is in the cache like Contains, but also
+// removes the entry if it has expired.
+func (c *Cache) ContainsOrExpire(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.ContainsOrExpire(key)
+}
+
+// ContainsAll reports whether every one of keys is in the cache, without
+// updating their recent-ness.
+func (c *Cache) ContainsAll(keys ...interface{}) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ContainsAll(keys...)
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness, and if not, adds the value. An expired entry counts as absent
+// and is replaced. Returns whether found and whether an eviction occurred.
 func (c *Cache) ContainsOrAdd(key, value interface{}) (ok, evicted bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	return c.lru.ContainsOrAdd(key, value)
+}
 
-	if c.lru.Contains(key) {
-		return true, false
-	}
-	evicted = c.lru.Add(key, value)
-	return false, evicted
+// ReplaceOrAdd adds a value to the cache like Add, additionally returning the
+// value it replaced and whether key held a live entry.
+func (c *Cache) ReplaceOrAdd(key, value interface{}) (previous interface{}, existed, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.ReplaceOrAdd(key, value)
 }
 
-// Remove removes the provided key from the cache.
-func (c *Cache) Remove(key interface{}) {
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness, and if not, adds the value. An expired entry counts as absent
+// and is replaced. Returns the existing value if found, whether found and
+// whether an eviction occurred.
+func (c *Cache) PeekOrAdd(key, value interface{}) (previous interface{}, ok, evicted bool) {
 	c.lock.Lock()
-	c.lru.Remove(key)
-	c.lock.Unlock()
+	defer c.lock.Unlock()
+	return c.lru.PeekOrAdd(key, value)
+}
+
+// AddIfAbsent stores value for key unless key already holds a live entry, in
+// which case the existing value is returned with loaded true.
+func (c *Cache) AddIfAbsent(key, value interface{}) (actual interface{}, loaded bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddIfAbsent(key, value)
+}
+
+// PurgeNoCallback is used to completely clear the cache without firing the
+// evict callback.
+func (c *Cache) PurgeNoCallback() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.PurgeNoCallback()
+}
+
+// Drain empties the cache, returning its live entries from newest to oldest.
+func (c *Cache) Drain() []simplelru.Entry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Drain()
+}
+
+// GetNoPromote looks up a key's value without updating the "recently
+// used"-ness of the key, but fires the acquire callback on a hit.
+func (c *Cache) GetNoPromote(key interface{}) (value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetNoPromote(key)
+}
+
+// GetHint looks up a key's value like Get if promote is true, or like
+// GetNoPromote if it is false.
+func (c *Cache) GetHint(key interface{}, promote bool) (value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetHint(key, promote)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Cache) Remove(key interface{}) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Remove(key)
+}
+
+// PeekAndRemove removes the provided key from the cache and returns its
+// value, if the key was contained.
+func (c *Cache) PeekAndRemove(key interface{}) (value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.PeekAndRemove(key)
+}
+
+// LoadAndDelete removes the provided key from the cache and returns its
+// value, firing the eviction callback, like sync.Map's LoadAndDelete. The
+// read and the removal happen under one lock, so of many concurrent calls
+// for a key at most one reports it as loaded.
+func (c *Cache) LoadAndDelete(key interface{}) (value interface{}, loaded bool) {
+	return c.PeekAndRemove(key)
+}
+
+// RemoveOldestN removes up to n of the oldest entries from the cache,
+// returning them from oldest to newest.
+func (c *Cache) RemoveOldestN(n int) (removed []simplelru.Entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.RemoveOldestN(n)
+}
+
+// RemoveOlderThan removes every entry whose value was added more than d ago,
+// returning how many were removed.
+func (c *Cache) RemoveOlderThan(d time.Duration) (removed int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.RemoveOlderThan(d)
+}
+
+// RemoveFunc removes every entry for which match returns true, returning how
+// many were removed. match is called with the cache locked and must not call
+// back into the cache.
+func (c *Cache) RemoveFunc(match func(key, value interface{}) bool) (removed int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.RemoveFunc(match)
 }
 
 // RemoveOldest removes the oldest item from the cache.
@@ -124,6 +617,46 @@ func (c *Cache) RemoveOldest() {
 	c.lock.Unlock()
 }
 
+// PeekNewest returns the newest entry without updating the "recently
+// used"-ness of the key.
+func (c *Cache) PeekNewest() (key interface{}, value interface{}, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.PeekNewest()
+}
+
+// Position returns how many entries are more recently used than key, so 0
+// is the newest entry, without updating the recent-ness of any key.
+func (c *Cache) Position(key interface{}) (pos int, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Position(key)
+}
+
+// AccessCount returns how many times Get or Add found key in the cache since
+// it was added, without updating its recent-ness.
+func (c *Cache) AccessCount(key interface{}) (count uint64, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.AccessCount(key)
+}
+
+// HotKeys returns up to n keys with the highest access counts, most accessed
+// first.
+func (c *Cache) HotKeys(n int) []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.HotKeys(n)
+}
+
+// ToMap returns a new map holding every live key and value in the cache,
+// without updating the recent-ness of any key.
+func (c *Cache) ToMap() map[interface{}]interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ToMap()
+}
+
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *Cache) Keys() []interface{} {
 	c.lock.RLock()
@@ -131,9 +664,203 @@ func (c *Cache) Keys() []interface{} {
 	return c.lru.Keys()
 }
 
+// KeysMatching returns the keys in the cache for which pred returns true,
+// from oldest to newest.
+func (c *Cache) KeysMatching(pred func(key interface{}) bool) []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.KeysMatching(pred)
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest.
+func (c *Cache) Values() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Values()
+}
+
 // Len returns the number of items in the cache.
 func (c *Cache) Len() int {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	return c.lru.Len()
 }
+
+// Cap returns the maximum number of items the cache holds.
+func (c *Cache) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Cap()
+}
+
+// RecentlyEvicted returns the keys most recently evicted to make room, from
+// oldest to newest, for a cache constructed with simplelru.WithGhostList.
+func (c *Cache) RecentlyEvicted() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.RecentlyEvicted()
+}
+
+// ThrashRate returns the fraction of new entries whose key was among the
+// recently evicted ones.
+func (c *Cache) ThrashRate() float64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ThrashRate()
+}
+
+// Pressure returns a moving average of the fraction of adds that caused an
+// eviction, a sign that the cache is too small when it nears 1.
+func (c *Cache) Pressure() float64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Pressure()
+}
+
+// Bytes returns the total size of the entries in the cache as estimated by
+// its size function.
+func (c *Cache) Bytes() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Bytes()
+}
+
+// ApproxBytes returns the estimated memory footprint of the entries in the
+// cache, or -1 if it was not constructed with simplelru.WithSizeOf.
+func (c *Cache) ApproxBytes() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ApproxBytes()
+}
+
+// GetRef looks up a key's value like Get, taking a reference to the key that
+// defers its evict callbacks until it is given back with Release.
+func (c *Cache) GetRef(key interface{}) (value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.GetRef(key)
+}
+
+// Release gives back a reference taken by GetRef, firing any deferred evict
+// callbacks once the last reference to the key is released.
+func (c *Cache) Release(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Release(key)
+}
+
+// AddWithMeta adds a value to the cache like Add, attaching meta to the
+// entry in place of any metadata it had.
+func (c *Cache) AddWithMeta(key, value interface{}, meta map[string]interface{}) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddWithMeta(key, value, meta)
+}
+
+// GetMeta returns the metadata of a key without updating its recent-ness.
+func (c *Cache) GetMeta(key interface{}) (meta map[string]interface{}, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.GetMeta(key)
+}
+
+// AddDirty adds a value to the cache like Add, marking it as modified so
+// that the write-back callback fires when it leaves the cache.
+func (c *Cache) AddDirty(key, value interface{}) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.AddDirty(key, value)
+}
+
+// MarkClean clears the dirty mark of a key. Returns whether the key was
+// present.
+func (c *Cache) MarkClean(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.MarkClean(key)
+}
+
+// DirtyKeys returns the keys of the dirty entries, from oldest to newest.
+func (c *Cache) DirtyKeys() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.DirtyKeys()
+}
+
+// Pin protects the entry for key from capacity-driven eviction until Unpin is
+// called. Returns whether the key was present.
+func (c *Cache) Pin(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Pin(key)
+}
+
+// Unpin makes the entry for key evictable again. Returns whether the key was
+// present.
+func (c *Cache) Unpin(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Unpin(key)
+}
+
+// Freeze suspends capacity-driven eviction until Unfreeze is called.
+func (c *Cache) Freeze() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.Freeze()
+}
+
+// Unfreeze resumes capacity-driven eviction, evicting the entries the cache
+// has grown by while frozen. Returns the number of evicted entries.
+func (c *Cache) Unfreeze() (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Unfreeze()
+}
+
+// Events returns a channel that receives an simplelru.Event for every add,
+// lookup hit, eviction and removal. Events are dropped rather than block
+// the cache when the channel is full.
+func (c *Cache) Events() <-chan simplelru.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Events()
+}
+
+// CloseEvents closes the channel returned by Events and stops sending
+// events.
+func (c *Cache) CloseEvents() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.CloseEvents()
+}
+
+// Stats returns a snapshot of the hit, miss, eviction and insertion counters.
+func (c *Cache) Stats() simplelru.Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Stats()
+}
+
+// ResetStats zeroes the hit, miss, eviction and insertion counters.
+func (c *Cache) ResetStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.ResetStats()
+}
+
+// StatsAndReset returns the counters and zeroes them under a single lock
+// acquisition, so that no operation is counted in two intervals or lost
+// between them.
+func (c *Cache) StatsAndReset() simplelru.Stats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.StatsAndReset()
+}
+
+// Resize changes the cache size, returning the number of evicted entries.
+func (c *Cache) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Resize(size)
+}
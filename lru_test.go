@@ -1,8 +1,16 @@
 package lru
 
 import (
+	"context"
 	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/rubrikinc/golang-lru/simplelru"
 )
 
 func BenchmarkLRU_Rand(b *testing.B) {
@@ -219,3 +227,555 @@ func TestLRUPeek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+// test that Remove reports whether the key was present
+func TestLRURemove(t *testing.T) {
+	l, err := NewCache(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	if !l.Remove(1) {
+		t.Errorf("1 should have been removed")
+	}
+	if l.Remove(1) {
+		t.Errorf("1 should not be contained")
+	}
+}
+
+// test that concurrent goroutines can share one cache
+func TestLRUConcurrent(t *testing.T) {
+	l, err := NewCacheWithEvict(64, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := (g*1000 + i) % 128
+				l.Add(k, k)
+				l.Get(k)
+				l.Peek(k)
+				l.Contains(k)
+				l.Keys()
+				if i%10 == 0 {
+					l.Remove(k)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if l.Len() > 64 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+// test that Resize can shrink the cache
+func TestLRUResize(t *testing.T) {
+	l, err := New(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	if evicted := l.Resize(2); evicted != 2 {
+		t.Errorf("2 elements should have been evicted: %v", evicted)
+	}
+	if l.Len() != 2 || l.Contains(0) || l.Contains(1) {
+		t.Errorf("oldest elements should have been evicted")
+	}
+}
+
+// test that PeekOrAdd doesn't update recent-ness
+func TestLRUPeekOrAdd(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	previous, contains, evict := l.PeekOrAdd(1, 1)
+	if !contains {
+		t.Errorf("1 should be contained")
+	}
+	if evict {
+		t.Errorf("nothing should be evicted here")
+	}
+	if previous != 1 {
+		t.Errorf("previous is not equal to 1")
+	}
+
+	l.Add(3, 3)
+	contains, evict = l.ContainsOrAdd(1, 1)
+	if contains {
+		t.Errorf("1 should not have been contained")
+	}
+	if !evict {
+		t.Errorf("an eviction should have occurred")
+	}
+	if !l.Contains(1) {
+		t.Errorf("now 1 should be contained")
+	}
+}
+
+// test that the janitor reclaims expired entries without them being accessed
+func TestLRUJanitor(t *testing.T) {
+	var mu sync.Mutex
+	evicted := make(map[interface{}]bool)
+	l, err := NewWithTTL(8, 10*time.Millisecond, func(k, v interface{}) {
+		mu.Lock()
+		evicted[k] = true
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.AddWithTTL(3, 3, time.Hour)
+
+	l.StartJanitor(0) // a non-positive interval is ignored
+	l.StartJanitor(-time.Second)
+	l.StartJanitor(5 * time.Millisecond)
+	l.StartJanitor(5 * time.Millisecond) // second start is a no-op
+	defer l.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for l.Len() > 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if l.Len() != 1 || !l.Contains(3) {
+		t.Fatalf("expired entries should have been reclaimed: %v", l.Keys())
+	}
+	mu.Lock()
+	if !evicted[1] || !evicted[2] {
+		t.Errorf("onEvict should fire for reclaimed entries: %v", evicted)
+	}
+	mu.Unlock()
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("second Close should be a no-op: %v", err)
+	}
+	l.StopJanitor()
+}
+
+// test that GetMulti and AddMulti work through the locked wrapper
+func TestLRUGetMulti(t *testing.T) {
+	l, err := New(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddMulti(map[interface{}]interface{}{1: 1, 2: 2})
+	found, missing := l.GetMulti([]interface{}{1, 2, 3})
+	if len(found) != 2 || len(missing) != 1 || missing[0] != 3 {
+		t.Errorf("bad: %v, %v", found, missing)
+	}
+}
+
+// test that concurrent misses for a key share one loader invocation
+func TestLRUGetOrLoad(t *testing.T) {
+	l, err := New(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var loads int32
+	release := make(chan struct{})
+	loader := func(k interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "value", nil
+	}
+
+	// Hold the loader until every other caller has joined its flight, so
+	// that all the misses overlap
+	const n = 50
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := l.GetOrLoad("key", loader)
+			if err != nil {
+				t.Errorf("err: %v", err)
+			}
+			results[i] = v
+		}(i)
+	}
+	waitForFlight(t, &l.loads, "key", n-1)
+	close(release)
+	wg.Wait()
+
+	if loads != 1 {
+		t.Fatalf("loader should have been invoked once: %v", loads)
+	}
+	for i, v := range results {
+		if v != "value" {
+			t.Fatalf("bad result %d: %v", i, v)
+		}
+	}
+	if v, ok := l.Get("key"); !ok || v != "value" {
+		t.Fatalf("loaded value should be cached: %v, %v", v, ok)
+	}
+}
+
+// waitForFlight blocks until dups callers have joined the call in flight
+// for key.
+func waitForFlight(t *testing.T, g *flightGroup, key interface{}, dups int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		c := g.calls[key]
+		joined := c != nil && c.dups >= dups
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers should have joined the flight for %v", dups, key)
+		}
+		runtime.Gosched()
+	}
+}
+
+// test that a panicking loader fails its waiters without wedging the key
+func TestLRUGetOrLoadPanic(t *testing.T) {
+	l, err := New(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	release := make(chan struct{})
+	loader := func(k interface{}) (interface{}, error) {
+		<-release
+		panic("boom")
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	panics := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { panics[i] = recover() }()
+			l.GetOrLoad("key", loader)
+		}(i)
+	}
+	waitForFlight(t, &l.loads, "key", n-1)
+	close(release)
+	wg.Wait()
+
+	for i, p := range panics {
+		if fp, ok := p.(*flightPanic); !ok || fp.value != "boom" {
+			t.Fatalf("caller %d should have panicked with the loader: %v", i, p)
+		}
+	}
+	v, err := l.GetOrLoad("key", func(k interface{}) (interface{}, error) {
+		return "value", nil
+	})
+	if err != nil || v != "value" {
+		t.Fatalf("key should load again after the panic: %v, %v", v, err)
+	}
+}
+
+type blockingFetcher struct {
+	fetches   int32
+	started   chan struct{}
+	release   chan struct{}
+	cancelled chan struct{} // closed if the fetch context is done, if not nil
+}
+
+func (f *blockingFetcher) Fetch(ctx context.Context, key interface{}) (interface{}, error) {
+	if atomic.AddInt32(&f.fetches, 1) == 1 {
+		close(f.started)
+	}
+	select {
+	case <-f.release:
+		return key, nil
+	case <-ctx.Done():
+		if f.cancelled != nil {
+			close(f.cancelled)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// test that a slow fetch neither blocks other lookups nor runs twice
+func TestLRUGetOrFetch(t *testing.T) {
+	f := &blockingFetcher{started: make(chan struct{}), release: make(chan struct{})}
+	l, err := NewWithOptions(8, simplelru.WithFetcher(f))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("other", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.GetOrFetch(context.Background(), "key"); err != nil || v != "key" {
+				t.Errorf("bad: %v, %v", v, err)
+			}
+		}()
+	}
+
+	<-f.started
+	if v, ok := l.Get("other"); !ok || v != 1 {
+		t.Fatalf("Get should not wait for the fetch: %v, %v", v, ok)
+	}
+	if _, ok := l.Peek("key"); ok {
+		t.Fatalf("key should not be cached before the fetch returns")
+	}
+	close(f.release)
+	wg.Wait()
+
+	if f.fetches != 1 {
+		t.Fatalf("fetcher should have been invoked once: %v", f.fetches)
+	}
+	if v, ok := l.Peek("key"); !ok || v != "key" {
+		t.Fatalf("fetched value should be cached: %v, %v", v, ok)
+	}
+}
+
+// test that the caller that started a fetch can give up without failing the
+// others waiting for it
+func TestLRUGetOrFetchFirstCancels(t *testing.T) {
+	f := &blockingFetcher{started: make(chan struct{}), release: make(chan struct{})}
+	l, err := NewWithOptions(8, simplelru.WithFetcher(f))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := l.GetOrFetch(ctx, "key")
+		first <- err
+	}()
+	<-f.started
+	second := make(chan error, 1)
+	go func() {
+		v, err := l.GetOrFetch(context.Background(), "key")
+		if err == nil && v != "key" {
+			t.Errorf("bad value: %v", v)
+		}
+		second <- err
+	}()
+	waitForFlight(t, &l.loads, "key", 1)
+
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Fatalf("first caller should stop waiting when cancelled: %v", err)
+	}
+	close(f.release)
+	if err := <-second; err != nil {
+		t.Fatalf("second caller should get the fetched value: %v", err)
+	}
+	if f.fetches != 1 {
+		t.Fatalf("fetcher should have been invoked once: %v", f.fetches)
+	}
+	if v, ok := l.Peek("key"); !ok || v != "key" {
+		t.Fatalf("fetched value should be cached: %v, %v", v, ok)
+	}
+}
+
+// test that a fetch is cancelled once every caller has given up on it
+func TestLRUGetOrFetchAllCancel(t *testing.T) {
+	f := &blockingFetcher{
+		started:   make(chan struct{}),
+		release:   make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+	l, err := NewWithOptions(8, simplelru.WithFetcher(f))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.GetOrFetch(ctx, "key")
+		done <- err
+	}()
+	<-f.started
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("caller should stop waiting when cancelled: %v", err)
+	}
+	<-f.cancelled
+}
+
+// test that GetOrLoad shares one load between keys that normalize alike
+func TestLRUGetOrLoadNormalized(t *testing.T) {
+	l, err := NewWithOptions(8, simplelru.WithKeyNormalizer(func(k interface{}) interface{} {
+		return strings.ToLower(k.(string))
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var loads int32
+	release := make(chan struct{})
+	loader := func(k interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"key", "KEY", "Key", "kEy"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if v, err := l.GetOrLoad(key, loader); err != nil || v != "value" {
+				t.Errorf("bad: %v, %v", v, err)
+			}
+		}(key)
+	}
+	waitForFlight(t, &l.loads, "key", 3)
+	close(release)
+	wg.Wait()
+
+	if loads != 1 {
+		t.Fatalf("loader should have been invoked once: %v", loads)
+	}
+}
+
+// test that concurrent GetOrAdd calls for one key agree on a single value
+func TestLRUGetOrAddAtomic(t *testing.T) {
+	l, err := New(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	const n = 64
+	var wg sync.WaitGroup
+	var adds, factories int32
+	results := make([]interface{}, n)
+	built := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, _, added := l.GetOrAdd("key", i)
+			if added {
+				atomic.AddInt32(&adds, 1)
+			}
+			results[i] = v
+			built[i], _ = l.GetOrAddWith("built", func() interface{} {
+				atomic.AddInt32(&factories, 1)
+				return i
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if adds != 1 || factories != 1 {
+		t.Fatalf("exactly one caller should add: %v adds, %v factories", adds, factories)
+	}
+	want, _ := l.Peek("key")
+	wantBuilt, _ := l.Peek("built")
+	for i := 0; i < n; i++ {
+		if results[i] != want || built[i] != wantBuilt {
+			t.Fatalf("bad result %d: %v, %v", i, results[i], built[i])
+		}
+	}
+}
+
+// test that each entry is consumed by exactly one concurrent LoadAndDelete
+func TestLRULoadAndDelete(t *testing.T) {
+	var evicted int32
+	l, err := NewWithEvict(128, func(k, v interface{}) {
+		atomic.AddInt32(&evicted, 1)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+
+	var wg sync.WaitGroup
+	var loaded int32
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 128; i++ {
+				if v, ok := l.LoadAndDelete(i); ok {
+					if v != i {
+						t.Errorf("bad value for %d: %v", i, v)
+					}
+					atomic.AddInt32(&loaded, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if loaded != 128 || evicted != 128 || l.Len() != 0 {
+		t.Fatalf("bad: %v loaded, %v evicted, len %v", loaded, evicted, l.Len())
+	}
+}
+
+// test that interval counters read by StatsAndReset add up to the totals
+func TestLRUStatsAndReset(t *testing.T) {
+	l, err := New(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("hit", 1)
+	l.ResetStats()
+
+	const workers, ops = 4, 1000
+	var wg sync.WaitGroup
+	for g := 0; g < workers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				l.Get("hit")
+				l.Get("miss")
+			}
+		}()
+	}
+
+	var hits, misses uint64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		s := l.StatsAndReset()
+		hits += s.Hits
+		misses += s.Misses
+	}
+
+	if hits != workers*ops || misses != workers*ops {
+		t.Fatalf("intervals should add up: %v hits, %v misses", hits, misses)
+	}
+}
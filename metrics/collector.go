@@ -0,0 +1,60 @@
+// Package metrics exports the statistics of an lru.Cache to Prometheus.
+//
+// It is a separate module so that the lru package itself stays free of
+// third-party dependencies.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	lru "github.com/rubrikinc/golang-lru"
+)
+
+// Collector is a prometheus.Collector reporting the hit, miss and eviction
+// counters of a cache along with its current length and capacity.
+type Collector struct {
+	cache *lru.Cache
+
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	length    *prometheus.Desc
+	capacity  *prometheus.Desc
+}
+
+// NewCollector creates a Collector for cache. Metric names are prefixed with
+// namespace, and constLabels are attached to every metric so that several
+// caches can be registered with the same registry.
+func NewCollector(cache *lru.Cache, namespace string, constLabels prometheus.Labels) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lru", name), help, nil, constLabels)
+	}
+	return &Collector{
+		cache:     cache,
+		hits:      desc("hits_total", "Number of lookups that found a live entry."),
+		misses:    desc("misses_total", "Number of lookups that found no live entry."),
+		evictions: desc("evictions_total", "Number of entries removed from the cache."),
+		length:    desc("entries", "Number of entries currently in the cache."),
+		capacity:  desc("capacity", "Maximum number of entries the cache holds."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.length
+	ch <- c.capacity
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.length, prometheus.GaugeValue, float64(c.cache.Len()))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(c.cache.Cap()))
+}
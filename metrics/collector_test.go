@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	lru "github.com/rubrikinc/golang-lru"
+)
+
+func TestCollector(t *testing.T) {
+	l, err := lru.New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewCollector(l, "test", prometheus.Labels{"cache": "a"})); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1
+	l.Get(2)
+	l.Get(3)
+	l.Get(1)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		m := mf.GetMetric()[0]
+		if c := m.GetCounter(); c != nil {
+			got[mf.GetName()] = c.GetValue()
+		} else {
+			got[mf.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+
+	want := map[string]float64{
+		"test_lru_hits_total":      2,
+		"test_lru_misses_total":    1,
+		"test_lru_evictions_total": 1,
+		"test_lru_entries":         2,
+		"test_lru_capacity":        2,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("bad %s: %v != %v", name, got[name], v)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/rubrikinc/golang-lru/metrics
+
+go 1.20
+
+require (
+	github.com/prometheus/client_golang v1.19.0
+	github.com/rubrikinc/golang-lru v0.6.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
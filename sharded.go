@@ -0,0 +1,139 @@
+package lru
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/rubrikinc/golang-lru/simplelru"
+)
+
+// KeyHasher maps a key to a hash used to select the shard holding it.
+type KeyHasher func(key interface{}) uint64
+
+// ShardedCache is a thread-safe fixed size LRU cache that spreads its keys
+// across several independently locked LRU shards. This reduces lock
+// contention under heavy concurrent access, at the cost of recency being
+// tracked per shard rather than across the whole cache.
+type ShardedCache struct {
+	shards []*Cache
+	hasher KeyHasher
+}
+
+// NewSharded creates a ShardedCache of the given total size split across
+// the given number of shards, using the default key hasher.
+func NewSharded(size, shards int) (*ShardedCache, error) {
+	return NewShardedWithHasher(size, shards, nil)
+}
+
+// NewShardedWithHasher creates a ShardedCache of the given total size split
+// across the given number of shards, routing keys with hasher. A nil hasher
+// selects DefaultKeyHasher.
+func NewShardedWithHasher(size, shards int, hasher KeyHasher) (*ShardedCache, error) {
+	if shards <= 0 {
+		return nil, fmt.Errorf("invalid shard count")
+	}
+	if size < shards {
+		return nil, fmt.Errorf("invalid size")
+	}
+	if hasher == nil {
+		hasher = DefaultKeyHasher
+	}
+
+	// Spread any remainder over the first shards so the capacities add up
+	// to size.
+	c := &ShardedCache{
+		shards: make([]*Cache, shards),
+		hasher: hasher,
+	}
+	for i := range c.shards {
+		shardSize := size / shards
+		if i < size%shards {
+			shardSize++
+		}
+		shard, err := New(shardSize)
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = shard
+	}
+	return c, nil
+}
+
+// DefaultKeyHasher hashes the fmt-printed form of the key with FNV-1a.
+func DefaultKeyHasher(key interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	return h.Sum64()
+}
+
+// shard returns the shard responsible for key.
+func (c *ShardedCache) shard(key interface{}) *Cache {
+	return c.shards[c.hasher(key)%uint64(len(c.shards))]
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ShardedCache) Add(key, value interface{}) (evicted bool) {
+	return c.shard(key).Add(key, value)
+}
+
+// Get looks up a key's value from the cache.
+func (c *ShardedCache) Get(key interface{}) (value interface{}, ok bool) {
+	return c.shard(key).Get(key)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *ShardedCache) Remove(key interface{}) (present bool) {
+	return c.shard(key).Remove(key)
+}
+
+// Len returns the number of items in the cache, summed across shards.
+func (c *ShardedCache) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Purge is used to completely clear the cache.
+func (c *ShardedCache) Purge() {
+	for _, shard := range c.shards {
+		shard.Purge()
+	}
+}
+
+// ShardStats holds the counters and length of one shard of a ShardedCache.
+type ShardStats struct {
+	simplelru.Stats
+	Len int
+}
+
+// ShardStats returns the statistics of every shard, in shard order. Each
+// shard is read under its own lock, so the result is not an atomic snapshot
+// of the whole cache.
+func (c *ShardedCache) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(c.shards))
+	for i, shard := range c.shards {
+		stats[i] = ShardStats{Stats: shard.Stats(), Len: shard.Len()}
+	}
+	return stats
+}
+
+// LoadFactor returns the length of the fullest shard divided by the average
+// shard length. It is 1 when keys are spread evenly, grows as they skew
+// towards fewer shards, and is 0 for an empty cache.
+func (c *ShardedCache) LoadFactor() float64 {
+	total, max := 0, 0
+	for _, shard := range c.shards {
+		n := shard.Len()
+		total += n
+		if n > max {
+			max = n
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(max) * float64(len(c.shards)) / float64(total)
+}
@@ -0,0 +1,139 @@
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkShardedParallel(b *testing.B) {
+	l, err := NewSharded(8192, 16)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkParallel(b, l.Add, l.Get)
+}
+
+func BenchmarkLockedParallel(b *testing.B) {
+	l, err := New(8192)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkParallel(b, l.Add, l.Get)
+}
+
+func benchmarkParallel(
+	b *testing.B,
+	add func(key, value interface{}) bool,
+	get func(key interface{}) (interface{}, bool),
+) {
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Int63() % 16384
+			if k%2 == 0 {
+				add(k, k)
+			} else {
+				get(k)
+			}
+		}
+	})
+}
+
+func TestSharded(t *testing.T) {
+	l, err := NewSharded(128, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() > 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	for i := 1000; i < 1010; i++ {
+		l.Add(i, i)
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+	}
+
+	if !l.Remove(1009) {
+		t.Errorf("1009 should have been removed")
+	}
+	if _, ok := l.Get(1009); ok {
+		t.Errorf("1009 should be deleted")
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestShardedCapacity(t *testing.T) {
+	// Route everything to one shard to check per-shard capacity
+	l, err := NewShardedWithHasher(10, 3, func(key interface{}) uint64 {
+		return 0
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 4 {
+		t.Errorf("first shard should hold 4 entries: %v", l.Len())
+	}
+
+	if _, err := NewSharded(2, 4); err == nil {
+		t.Errorf("size smaller than shard count should fail")
+	}
+	if _, err := NewSharded(8, 0); err == nil {
+		t.Errorf("zero shards should fail")
+	}
+}
+
+// Test that per-shard statistics expose a skewed key distribution
+func TestShardedLoadFactor(t *testing.T) {
+	l, err := NewShardedWithHasher(64, 4, func(key interface{}) uint64 {
+		// Send even keys to shard 0 and spread odd keys over the rest
+		k := uint64(key.(int))
+		if k%2 == 0 {
+			return 0
+		}
+		return k
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if f := l.LoadFactor(); f != 0 {
+		t.Fatalf("empty cache should have zero load factor: %v", f)
+	}
+
+	for i := 1; i <= 6; i += 2 {
+		l.Add(i, i) // 1 -> shard 1, 3 -> shard 3, 5 -> shard 1
+	}
+	for i := 0; i < 10; i += 2 {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	l.Get(100)
+
+	stats := l.ShardStats()
+	if len(stats) != 4 {
+		t.Fatalf("bad shard count: %v", len(stats))
+	}
+	if stats[0].Len != 5 || stats[0].Hits != 1 || stats[0].Misses != 1 {
+		t.Errorf("bad shard 0: %+v", stats[0])
+	}
+	if stats[1].Len != 2 || stats[2].Len != 0 || stats[3].Len != 1 {
+		t.Errorf("bad shards: %+v", stats)
+	}
+
+	// 8 keys over 4 shards averages 2, and shard 0 holds 5
+	if f := l.LoadFactor(); f != 2.5 {
+		t.Errorf("bad load factor: %v", f)
+	}
+}
@@ -0,0 +1,205 @@
+package simplelru
+
+import (
+	"container/list"
+	"errors"
+)
+
+// LFU implements a non-thread safe fixed size LFU cache. It evicts the least
+// frequently used entry, breaking ties by evicting the least recently used
+// one. Every Add or Get of a key counts as a use.
+type LFU struct {
+	size      int
+	items     map[interface{}]*list.Element
+	freqs     map[uint64]*list.List // recency-ordered entries per frequency
+	minFreq   uint64
+	onAcquire AcquireCallback
+	onEvict   EvictCallback
+}
+
+// lfuEntry is used to hold a value in the frequency lists
+type lfuEntry struct {
+	key   interface{}
+	value interface{}
+	freq  uint64
+}
+
+// NewLFUWithAcquireAndEvict constructs a fixed size LFU cache with the given
+// acquire and eviction callbacks.
+func NewLFUWithAcquireAndEvict(
+	size int,
+	onAcquire AcquireCallback,
+	onEvict EvictCallback,
+) (*LFU, error) {
+	if size <= 0 {
+		return nil, errors.New("Must provide a positive size")
+	}
+	c := &LFU{
+		size:      size,
+		items:     make(map[interface{}]*list.Element),
+		freqs:     make(map[uint64]*list.List),
+		onAcquire: onAcquire,
+		onEvict:   onEvict,
+	}
+	return c, nil
+}
+
+// NewLFUWithEvict constructs a fixed size LFU cache with the given eviction
+// callback.
+func NewLFUWithEvict(size int, onEvict EvictCallback) (*LFU, error) {
+	return NewLFUWithAcquireAndEvict(size, nil, onEvict)
+}
+
+// Purge is used to completely clear the cache. It allocates fresh maps, so
+// that the memory held by a large cache can be reclaimed.
+func (c *LFU) Purge() {
+	items := c.items
+	c.items = make(map[interface{}]*list.Element)
+	c.freqs = make(map[uint64]*list.List)
+	c.minFreq = 0
+	if c.onEvict != nil {
+		for k, v := range items {
+			c.onEvict(k, v.Value.(*lfuEntry).value)
+		}
+	}
+}
+
+// Add adds a value to the cache, counting as a use of the key.  Returns true
+// if an eviction occurred.
+func (c *LFU) Add(key, value interface{}) (evicted bool) {
+	// Check for existing item
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*lfuEntry).value = value
+		c.touch(ent)
+		if c.onAcquire != nil {
+			c.onAcquire(key, value)
+		}
+		return false
+	}
+
+	// Make room for the new item
+	if len(c.items) >= c.size {
+		c.removeLeastFrequent()
+		evicted = true
+	}
+
+	c.items[key] = c.bucket(1).PushFront(&lfuEntry{key: key, value: value, freq: 1})
+	c.minFreq = 1
+	if c.onAcquire != nil {
+		c.onAcquire(key, value)
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache, counting as a use of the key.
+func (c *LFU) Get(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.touch(ent)
+		value = ent.Value.(*lfuEntry).value
+		if c.onAcquire != nil {
+			c.onAcquire(key, value)
+		}
+		return value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating its use count.
+func (c *LFU) Contains(key interface{}) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// its use count.
+func (c *LFU) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*lfuEntry).value, true
+	}
+	return nil, false
+}
+
+// Frequency returns how many times key has been used, or 0 if the key is
+// not in the cache.
+func (c *LFU) Frequency(key interface{}) uint64 {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*lfuEntry).freq
+	}
+	return 0
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *LFU) Remove(key interface{}) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// Len returns the number of items in the cache.
+func (c *LFU) Len() int {
+	return len(c.items)
+}
+
+// bucket returns the list of entries with the given frequency, creating it
+// if needed.
+func (c *LFU) bucket(freq uint64) *list.List {
+	l, ok := c.freqs[freq]
+	if !ok {
+		l = list.New()
+		c.freqs[freq] = l
+	}
+	return l
+}
+
+// unlink removes e from its frequency list, dropping the list if it becomes
+// empty. Returns whether the list was dropped.
+func (c *LFU) unlink(e *list.Element) (emptied bool) {
+	freq := e.Value.(*lfuEntry).freq
+	l := c.freqs[freq]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(c.freqs, freq)
+		return true
+	}
+	return false
+}
+
+// touch records a use of the entry, moving it to the front of the next
+// frequency list.
+func (c *LFU) touch(e *list.Element) {
+	kv := e.Value.(*lfuEntry)
+	if c.unlink(e) && c.minFreq == kv.freq {
+		c.minFreq++
+	}
+	kv.freq++
+	c.items[kv.key] = c.bucket(kv.freq).PushFront(kv)
+}
+
+// removeLeastFrequent removes the least recently used of the least
+// frequently used entries.
+func (c *LFU) removeLeastFrequent() {
+	if l, ok := c.freqs[c.minFreq]; ok {
+		c.removeElement(l.Back())
+	}
+}
+
+// removeElement is used to remove a given list element from the cache
+func (c *LFU) removeElement(e *list.Element) {
+	kv := e.Value.(*lfuEntry)
+	if c.unlink(e) && c.minFreq == kv.freq {
+		// The next lowest frequency may be any of the remaining ones
+		c.minFreq = 0
+		for freq := range c.freqs {
+			if c.minFreq == 0 || freq < c.minFreq {
+				c.minFreq = freq
+			}
+		}
+	}
+	delete(c.items, kv.key)
+	if c.onEvict != nil {
+		c.onEvict(kv.key, kv.value)
+	}
+}
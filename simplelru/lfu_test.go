@@ -0,0 +1,137 @@
+package simplelru
+
+import "testing"
+
+func TestLFU(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	}
+	l, err := NewLFUWithEvict(128, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if evictCounter != 128 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+
+	// With equal frequencies the least recently used entries go first
+	for i := 0; i < 128; i++ {
+		if _, ok := l.Get(i); ok {
+			t.Fatalf("should be evicted")
+		}
+	}
+	for i := 128; i < 256; i++ {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("should not be evicted")
+		}
+	}
+	for i := 128; i < 192; i++ {
+		if !l.Remove(i) {
+			t.Fatalf("should be contained")
+		}
+		if l.Remove(i) {
+			t.Fatalf("should not be contained")
+		}
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, ok := l.Get(200); ok {
+		t.Fatalf("should contain nothing")
+	}
+}
+
+// Test that a frequently used key survives while a rarely used one is dropped
+func TestLFU_FrequentSurvives(t *testing.T) {
+	l, err := NewLFUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("hot", 1)
+	l.Add("cold", 2)
+	for i := 0; i < 5; i++ {
+		l.Get("hot")
+	}
+	l.Get("cold")
+
+	// "hot" is the least recently used, but the most frequently used
+	if !l.Add("new", 3) {
+		t.Errorf("should have an eviction")
+	}
+	if !l.Contains("hot") {
+		t.Errorf("frequently used key should survive")
+	}
+	if l.Contains("cold") {
+		t.Errorf("rarely used key should be evicted")
+	}
+	if f := l.Frequency("hot"); f != 6 {
+		t.Errorf("bad frequency: %v", f)
+	}
+}
+
+// Test that Peek and Contains don't count as uses
+func TestLFU_Peek(t *testing.T) {
+	l, err := NewLFUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(2)
+	if v, ok := l.Peek(1); !ok || v != 1 {
+		t.Errorf("1 should be set to 1: %v, %v", v, ok)
+	}
+	if !l.Contains(1) {
+		t.Errorf("1 should be contained")
+	}
+
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Errorf("Peek should not have counted as a use of 1")
+	}
+}
+
+// Test that removing the least frequently used entries keeps eviction working
+func TestLFU_RemoveMinFrequency(t *testing.T) {
+	l, err := NewLFUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	for i := 0; i < 3; i++ {
+		l.Get(2)
+		l.Get(3)
+	}
+	l.Get(3)
+	l.Remove(1)
+
+	l.Add(4, 4)
+	l.Get(4)
+	l.Remove(4)
+	l.Add(5, 5)
+	l.Add(6, 6)
+	if l.Len() != 3 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if !l.Contains(2) || !l.Contains(3) || !l.Contains(6) {
+		t.Errorf("least frequently used key 5 should have been evicted")
+	}
+}
@@ -2,9 +2,19 @@ package simplelru
 
 import (
 	"container/list"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
 )
 
+// ErrNegativeEntry is returned by the loading lookups when a key is cached
+// as negative by AddNegative.
+var ErrNegativeEntry = errors.New("simplelru: key is cached as negative")
+
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback func(key interface{}, value interface{})
 
@@ -12,19 +22,194 @@ type EvictCallback func(key interface{}, value interface{})
 // either through Add or Get.
 type AcquireCallback func(key interface{}, value interface{})
 
-// LRU implements a non-thread safe fixed size LRU cache
-type LRU struct {
-	size      int
-	evictList *list.List
-	items     map[interface{}]*list.Element
-	onAcquire AcquireCallback
-	onEvict   EvictCallback
+// MissCallback is used to get a callback when a Get finds no entry for a key.
+type MissCallback func(key interface{})
+
+// CostFunc is used to compute the cost of a cache entry, such as its
+// approximate size in memory, for caches bounded by total cost.
+type CostFunc func(key interface{}, value interface{}) int64
+
+// KeyNormalizer maps a key to the canonical form under which it is stored.
+type KeyNormalizer func(key interface{}) interface{}
+
+// SizeOfFunc estimates the memory footprint in bytes of an entry.
+type SizeOfFunc func(key interface{}, value interface{}) int64
+
+// LRUGeneric implements a non-thread safe fixed size LRU cache with typed
+// keys and values, avoiding the type assertions and interface boxing that
+// come with interface{} keys and values. The zero value is an empty cache
+// without a size bound; use a constructor to bound it.
+type LRUGeneric[K comparable, V any] struct {
+	size             int // zero for caches bounded only by cost, or not at all
+	evictList        list.List
+	items            map[K]*list.Element
+	onAcquire        func(key K, value V)
+	skipAcquireOnGet bool // set by WithFireAcquireOnGet(false)
+	insertionOrder   bool // set by WithAccessOrder(false)
+	rejectOnFull     bool
+	policy           interface{ Victim(c *LRUGeneric[K, V]) K } // nil evicts the oldest entry, see EvictionPolicy
+	tier             interface {
+		Set(key K, value V)
+		Get(key K) (value V, ok bool)
+	} // see Tier
+	fetcher interface {
+		Fetch(ctx context.Context, key K) (V, error)
+	} // see Fetcher and GetOrFetch
+	negativeFetchTTL time.Duration // zero leaves fetch errors uncached
+	admission        *sketch       // set by WithAdmissionFilter
+	frozen           bool
+	grace            float64 // see WithOverflowGrace
+	highWater        float64 // see WithHighWaterMark
+	onHighWater      func(len, cap int)
+	highWaterTripped bool
+	normalizer       func(key K) K
+	events           chan Event // nil until Events is called
+	eventBuffer      int
+	droppedEvents    uint64
+	onEvict          func(key K, value V)
+	onEvictErr       func(key K, value V) error
+	onEvictReason    func(key K, value V, reason EvictReason)
+	onWriteBack      func(key K, value V)
+	onEvictMeta      func(key K, value V, meta map[string]interface{})
+	onPanic          func(key K, value V, recovered interface{})
+	evictOnReplace   bool                  // set by WithFireEvictOnReplace
+	evictErrs        *EvictErrors          // non-nil while a checked operation runs
+	victims          *[]EntryGeneric[K, V] // non-nil while GetOrAddEvicted runs
+	ghosts           *ghostRing[K]         // set by WithGhostList
+	refs             map[K]int             // outstanding GetRef references
+	deferred         map[K][]deferredEviction[K, V]
+	onMiss           func(key K)
+	ttl              time.Duration
+	expiration       ExpirationMode
+	stats            Stats
+	pressure         float64 // see Pressure
+	pressureAlpha    float64 // zero selects defaultPressureSmoothing
+	costFunc         func(key K, value V) int64
+	maxCost          int64
+	currentCost      int64
+	sizeOf           func(key K, value V) int64
+	currentBytes     int64
+	maxBytes         int64 // zero leaves the size unbounded
+	decodeHook       func(key, value json.RawMessage) (K, V, error)
+	clock            func() time.Time // nil means time.Now
+	rand             *rand.Rand       // nil means the math/rand functions
+	epoch            uint64           // see BumpEpoch
+}
+
+// LRU is the LRUGeneric with interface{} keys and values, which predates
+// it. The options and the named callback types, such as EvictCallback, are
+// written for it.
+type LRU = LRUGeneric[interface{}, interface{}]
+
+// Stats holds the hit, miss, eviction and insertion counters of a cache.
+type Stats struct {
+	Hits       uint64 // Get lookups that found a live entry
+	Misses     uint64 // Get lookups that found nothing or an expired entry
+	Evictions  uint64 // Entries removed by capacity, expiry or Remove
+	Insertions uint64 // New entries added to the cache
 }
 
+// HitRatio returns the fraction of lookups that were hits, or 0 if there
+// have been no lookups.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// EntryGeneric is a key value pair exported from an LRUGeneric, for instance
+// by Snapshot.
+type EntryGeneric[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+	// TTL is the time the entry had left to live when taken by Snapshot, or
+	// 0 if it never expires. Other methods returning entries leave it 0.
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// Entry is the EntryGeneric of an LRU.
+type Entry = EntryGeneric[interface{}, interface{}]
+
 // entry is used to hold a value in the evictList
-type entry struct {
-	key   interface{}
-	value interface{}
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	ttl       time.Duration
+	expiresAt time.Time // zero if the entry never expires
+	addedAt   time.Time // when the current value was added, see RemoveOlderThan
+	cost      int64
+	bytes     int64     // estimated by sizeOf
+	accesses  uint64    // Get and Add hits, see AccessCount
+	epoch     uint64    // see BumpEpoch
+	ext       *entryExt // nil until one of its fields is needed
+}
+
+// entryExt holds the rarely used fields of an entry, so that caches not
+// using them do not pay for their space.
+type entryExt struct {
+	meta     map[string]interface{} // see AddWithMeta
+	pinned   bool                   // protected from capacity eviction, see Pin
+	negative bool                   // caches the absence of a value, see AddNegative
+	dirty    bool                   // modified since loaded, see AddDirty
+}
+
+// extra returns the entryExt of the entry, allocating it if needed.
+func (e *entry[K, V]) extra() *entryExt {
+	if e.ext == nil {
+		e.ext = new(entryExt)
+	}
+	return e.ext
+}
+
+func (e *entry[K, V]) pinned() bool   { return e.ext != nil && e.ext.pinned }
+func (e *entry[K, V]) negative() bool { return e.ext != nil && e.ext.negative }
+func (e *entry[K, V]) dirty() bool    { return e.ext != nil && e.ext.dirty }
+
+func (e *entry[K, V]) meta() map[string]interface{} {
+	if e.ext == nil {
+		return nil
+	}
+	return e.ext.meta
+}
+
+// expired reports whether the entry's deadline has passed or it was added
+// before the last BumpEpoch.
+func (e *entry[K, V]) expired(now *lazyNow) bool {
+	if e.epoch != now.epoch {
+		return true
+	}
+	return !e.expiresAt.IsZero() && !now.get().Before(e.expiresAt)
+}
+
+// lazyNow reads the clock at most once per operation, and only if an
+// expiry actually needs to be computed or checked. It also carries the
+// epoch of the cache, against which entries are checked.
+type lazyNow struct {
+	t     time.Time
+	clock func() time.Time
+	epoch uint64
+}
+
+func (n *lazyNow) get() time.Time {
+	if n.t.IsZero() {
+		if n.clock != nil {
+			n.t = n.clock()
+		} else {
+			n.t = time.Now()
+		}
+	}
+	return n.t
+}
+
+// deadline returns the expiry time of an entry added now with the given ttl,
+// or the zero time if ttl is not positive.
+func deadline(now *lazyNow, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.get().Add(ttl)
 }
 
 func NewLRUWithAcquireAndEvict(
@@ -32,170 +217,1635 @@ func NewLRUWithAcquireAndEvict(
 	onAcquire AcquireCallback,
 	onEvict EvictCallback,
 ) (*LRU, error) {
-	if size <= 0 {
+	return NewLRUGenericWithAcquireAndEvict[interface{}, interface{}](size, onAcquire, onEvict)
+}
+
+func NewLRUWithEvict(size int, onEvict EvictCallback) (*LRU, error) {
+	return NewLRUWithAcquireAndEvict(size, nil, onEvict)
+}
+
+// Option configures an LRU constructed with NewLRUWithOptions.
+type Option func(c *LRU) error
+
+// WithAcquireCallback sets the callback fired when an entry is acquired.
+func WithAcquireCallback(onAcquire AcquireCallback) Option {
+	return func(c *LRU) error {
+		c.onAcquire = onAcquire
+		return nil
+	}
+}
+
+// WithFireAcquireOnGet controls whether the acquire callback fires when Get,
+// GetOrAdd or another lookup hits an existing entry. It defaults to true;
+// with false the callback fires only when a value is added.
+func WithFireAcquireOnGet(fire bool) Option {
+	return func(c *LRU) error {
+		c.skipAcquireOnGet = !fire
+		return nil
+	}
+}
+
+// WithAccessOrder controls whether hits promote entries. It defaults to true;
+// with false neither Get, Add of an existing key nor Touch moves an entry,
+// so the cache evicts in insertion order, as a FIFO.
+func WithAccessOrder(access bool) Option {
+	return func(c *LRU) error {
+		c.insertionOrder = !access
+		return nil
+	}
+}
+
+// NewFIFO constructs a fixed size cache that evicts its oldest inserted
+// entries first, regardless of how they are accessed.
+func NewFIFO(size int, onEvict EvictCallback) (*LRU, error) {
+	return NewLRUWithOptions(size, WithEvictCallback(onEvict), WithAccessOrder(false))
+}
+
+// WithEvictCallback sets the callback fired when an entry is evicted.
+func WithEvictCallback(onEvict EvictCallback) Option {
+	return func(c *LRU) error {
+		c.onEvict = onEvict
+		return nil
+	}
+}
+
+// WithEvictReasonCallback sets a callback fired when an entry is evicted,
+// along with the reason it was evicted.
+func WithEvictReasonCallback(onEvict EvictCallbackWithReason) Option {
+	return func(c *LRU) error {
+		c.onEvictReason = onEvict
+		return nil
+	}
+}
+
+// WithMissCallback sets the callback fired when Get, or the lookup made by
+// GetOrAdd, finds no live entry for a key. It does not fire for Peek or
+// Contains.
+func WithMissCallback(onMiss MissCallback) Option {
+	return func(c *LRU) error {
+		c.onMiss = onMiss
+		return nil
+	}
+}
+
+// WithRejectOnFull makes a full cache refuse to add new keys instead of
+// evicting its oldest entries. Updating a key already in the cache still
+// succeeds. Use TryAdd to learn whether a value was stored.
+func WithRejectOnFull() Option {
+	return func(c *LRU) error {
+		c.rejectOnFull = true
+		return nil
+	}
+}
+
+// WithClock makes the cache read the current time from clock instead of
+// time.Now, for every TTL, expiry and age computation. It is meant for tests
+// that need to control the passage of time.
+func WithClock(clock func() time.Time) Option {
+	return func(c *LRU) error {
+		if clock == nil {
+			return errors.New("Must provide a clock")
+		}
+		c.clock = clock
+		return nil
+	}
+}
+
+// WithRandSource makes the cache draw the random numbers it needs, such as
+// the TTLs of AddMultiWithJitteredTTL, from src instead of the math/rand
+// functions. A seeded source makes them reproducible in tests. Like src, the
+// cache is then not safe for concurrent use even by clones, which share it.
+func WithRandSource(src rand.Source) Option {
+	return func(c *LRU) error {
+		if src == nil {
+			return errors.New("Must provide a random source")
+		}
+		c.rand = rand.New(src)
+		return nil
+	}
+}
+
+// WithSizeOf sets the function used by ApproxBytes to estimate the memory
+// footprint of each entry.
+func WithSizeOf(sizeOf SizeOfFunc) Option {
+	return func(c *LRU) error {
+		c.sizeOf = sizeOf
+		return nil
+	}
+}
+
+// WithOverflowGrace lets the cache grow to size * (1 + factor) entries
+// before evicting, and then evict back down to size in one batch. This
+// amortizes evictions when the working set is slightly larger than the
+// cache, at the cost of holding up to size * factor more entries in memory.
+func WithOverflowGrace(factor float64) Option {
+	return func(c *LRU) error {
+		if factor < 0 {
+			return errors.New("Must provide a non-negative grace factor")
+		}
+		c.grace = factor
+		return nil
+	}
+}
+
+// WithKeyNormalizer makes the cache pass every key given to its lookup,
+// add and remove methods through normalize, so that keys with the same
+// normalized form share one entry. The cache stores and reports the
+// normalized keys, for instance from Keys and to the callbacks. normalize
+// must be idempotent.
+func WithKeyNormalizer(normalize KeyNormalizer) Option {
+	return func(c *LRU) error {
+		c.normalizer = normalize
+		return nil
+	}
+}
+
+// WithUnbounded removes the size limit of the cache, overriding the size
+// passed to NewLRUWithOptions, which may then be 0, so that Add never evicts
+// for capacity. The
+// cache keeps its LRU order, and every other method works as usual, but it
+// grows without limit: only use it when the set of keys is known to be
+// bounded, or when entries are removed or expire some other way. Resize
+// with a positive size bounds the cache again.
+func WithUnbounded() Option {
+	return func(c *LRU) error {
+		c.size = 0
+		return nil
+	}
+}
+
+// NewLRUWithOptions constructs a fixed size cache configured by opts. size
+// must be positive unless opts include WithUnbounded.
+func NewLRUWithOptions(size int, opts ...Option) (*LRU, error) {
+	if size < 0 {
 		return nil, errors.New("Must provide a positive size")
 	}
 	c := &LRU{
-		size:      size,
-		evictList: list.New(),
-		items:     make(map[interface{}]*list.Element),
-		onEvict:   onEvict,
-		onAcquire: onAcquire,
+		size:  size,
+		items: make(map[interface{}]*list.Element),
+	}
+	if size == 0 {
+		// Left negative unless WithUnbounded clears it
+		c.size = -1
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.size < 0 {
+		return nil, errors.New("Must provide a positive size")
 	}
 	return c, nil
 }
 
-func NewLRUWithEvict(size int, onEvict EvictCallback) (*LRU, error) {
-	return NewLRUWithAcquireAndEvict(size, nil, onEvict)
+// NewLRUWithTTL constructs a fixed size cache whose entries added through Add
+// expire after defaultTTL. A non-positive defaultTTL means entries added
+// through Add never expire; AddWithTTL can still set a per-entry TTL. opts
+// can further configure the cache, for instance with WithExpirationMode.
+func NewLRUWithTTL(
+	size int,
+	defaultTTL time.Duration,
+	onEvict EvictCallback,
+	opts ...Option,
+) (*LRU, error) {
+	c, err := NewLRUWithOptions(size, append([]Option{WithEvictCallback(onEvict)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.ttl = defaultTTL
+	return c, nil
 }
 
-// Purge is used to completely clear the cache.
-func (c *LRU) Purge() {
-	for k, v := range c.items {
-		if c.onEvict != nil {
-			c.onEvict(k, v.Value.(*entry).value)
+// ExpirationMode selects how accesses affect an entry's expiry.
+type ExpirationMode int
+
+const (
+	// AbsoluteExpiration fixes an entry's deadline when its value is
+	// added; reading the entry does not extend it.
+	AbsoluteExpiration ExpirationMode = iota
+	// SlidingExpiration additionally pushes an entry's deadline back by its
+	// TTL every time it is accessed through Get or Touch.
+	SlidingExpiration
+)
+
+// WithExpirationMode sets how accesses affect the expiry of entries with a
+// TTL. The default is AbsoluteExpiration.
+func WithExpirationMode(mode ExpirationMode) Option {
+	return func(c *LRU) error {
+		if mode != AbsoluteExpiration && mode != SlidingExpiration {
+			return errors.New("invalid expiration mode")
 		}
-		delete(c.items, k)
+		c.expiration = mode
+		return nil
+	}
+}
+
+// NewLRUWithCost constructs a cache bounded by the total cost of its entries
+// rather than by their count. costFunc is called on every Add to compute the
+// entry's cost, and the oldest entries are evicted until the total cost is
+// within maxCost. An entry whose cost alone exceeds maxCost is evicted
+// immediately after being added.
+func NewLRUWithCost(
+	maxCost int64,
+	costFunc CostFunc,
+	onEvict EvictCallback,
+) (*LRU, error) {
+	if maxCost <= 0 {
+		return nil, errors.New("Must provide a positive max cost")
+	}
+	if costFunc == nil {
+		return nil, errors.New("Must provide a cost function")
 	}
+	c, err := NewLRUWithAcquireAndEvict(1, nil, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.size = 0
+	c.costFunc = costFunc
+	c.maxCost = maxCost
+	return c, nil
+}
+
+// NewLRUWithLimits constructs a cache bounded both by the count of its
+// entries and by their total size as estimated by sizeOf. Add evicts the
+// oldest entries until both limits are satisfied. An entry whose size alone
+// exceeds maxBytes is evicted immediately after being added.
+func NewLRUWithLimits(
+	maxItems int,
+	maxBytes int64,
+	sizeOf SizeOfFunc,
+	onEvict EvictCallback,
+) (*LRU, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("Must provide a positive max bytes")
+	}
+	if sizeOf == nil {
+		return nil, errors.New("Must provide a size function")
+	}
+	c, err := NewLRUWithAcquireAndEvict(maxItems, nil, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.sizeOf = sizeOf
+	c.maxBytes = maxBytes
+	return c, nil
+}
+
+// Purge is used to completely clear the cache. It allocates a fresh map, so
+// that the memory held by a large cache can be reclaimed.
+func (c *LRUGeneric[K, V]) Purge() {
+	// Clear first so a panicking callback leaves the cache consistent
+	items := c.items
+	c.PurgeNoCallback()
+	for _, v := range items {
+		c.evicted(v.Value.(*entry[K, V]), ReasonPurged)
+	}
+}
+
+// PurgeNoCallback completely clears the cache like Purge, without firing
+// the evict callbacks.
+func (c *LRUGeneric[K, V]) PurgeNoCallback() {
+	// Drop rather than clear the map, which would keep its grown buckets
+	c.items = make(map[K]*list.Element)
 	c.evictList.Init()
+	c.currentCost = 0
+	c.currentBytes = 0
+	c.highWaterTripped = false
+}
+
+// Drain empties the cache like Purge, returning its live entries from newest
+// to oldest. The evict callbacks fire for every entry, with ReasonPurged, or
+// ReasonExpired for expired entries, which are not returned.
+func (c *LRUGeneric[K, V]) Drain() []EntryGeneric[K, V] {
+	now := c.now()
+	drained := make([]*entry[K, V], 0, len(c.items))
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		drained = append(drained, ent.Value.(*entry[K, V]))
+	}
+	c.PurgeNoCallback()
+
+	entries := make([]EntryGeneric[K, V], 0, len(drained))
+	for _, kv := range drained {
+		if kv.expired(&now) {
+			c.evicted(kv, ReasonExpired)
+			continue
+		}
+		entries = append(entries, EntryGeneric[K, V]{Key: kv.key, Value: kv.value})
+		c.evicted(kv, ReasonPurged)
+	}
+	return entries
 }
 
 // GetOrAdd tries to lookup a key in the cache, returning the value.
 // Otherwise, add the key value pair, returning the value.
 // Along with if an eviction occurred and if value was added.
-func (c *LRU) GetOrAdd(key, value interface{}) (interface{}, bool, bool) {
+func (c *LRUGeneric[K, V]) GetOrAdd(key K, value V) (V, bool, bool) {
+	key = c.normalize(key)
+	now := c.now()
+
 	// Check for existing item.
-	if val, ok := c.Get(key); ok {
+	if val, ok := c.get(key, &now); ok {
 		return val, false, false // No eviction on Get.
 	}
 
 	// Add new item.
-	evicted := c.addItem(key, value)
+	evicted := c.addItem(key, value, c.ttl, &now)
 	return value, evicted, true
 }
 
+// GetOrAddWithCost is like GetOrAdd for a cache bounded by cost, adding
+// value on a miss with the given cost in place of the one computed by the
+// cost function, and evicting the oldest entries until the total cost is
+// within budget. On a hit the existing value is returned and its cost is
+// left unchanged. A later Add of the key recomputes the cost.
+func (c *LRUGeneric[K, V]) GetOrAddWithCost(key K, value V, cost int64) (actual V, added, evicted bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if val, ok := c.get(key, &now); ok {
+		return val, false, false
+	}
+	ent := c.newEntry(key, value, c.ttl, &now)
+	ent.cost = cost
+	evicted = c.insertEntry(ent)
+	return value, true, evicted
+}
+
+// GetOrAddWith tries to lookup a key in the cache, returning the value.
+// Otherwise it adds the value returned by factory, which is only called on a
+// miss, and returns it. Returns whether the value was added.
+func (c *LRUGeneric[K, V]) GetOrAddWith(key K, factory func() V) (value V, added bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if val, ok := c.get(key, &now); ok {
+		return val, false
+	}
+	value = factory()
+	c.addItem(key, value, c.ttl, &now)
+	return value, true
+}
+
+// GetOrAddEvicted is like GetOrAdd, additionally returning the entry that was
+// evicted to make room for value. When several entries are evicted, as can
+// happen in a cache bounded by cost, the oldest of them is returned.
+func (c *LRUGeneric[K, V]) GetOrAddEvicted(key K, value V) (
+	actual V,
+	added bool,
+	evictedKey K, evictedValue V,
+	evicted bool,
+) {
+	victims := c.recordVictims(func() {
+		actual, evicted, added = c.GetOrAdd(key, value)
+	})
+	if len(victims) > 0 {
+		evictedKey, evictedValue = victims[0].Key, victims[0].Value
+	}
+	return actual, added, evictedKey, evictedValue, evicted
+}
+
+// GetOrLoad looks up a key's value from the cache, and on a miss calls
+// loader to compute it. A successfully loaded value is added to the cache
+// and returned; if loader returns an error nothing is cached and the error
+// is returned. A negative entry returns ErrNegativeEntry without calling
+// loader. LRU is not thread safe, so nothing guards the cache while
+// loader runs; thread-safe wrappers are responsible for locking and for
+// deduplicating concurrent loads of the same key.
+func (c *LRUGeneric[K, V]) GetOrLoad(
+	key K,
+	loader func(key K) (V, error),
+) (value V, err error) {
+	key = c.normalize(key)
+	if v, ok, negative := c.Lookup(key); ok {
+		if negative {
+			return value, ErrNegativeEntry
+		}
+		return v, nil
+	}
+	v, err := loader(key)
+	if err != nil {
+		return value, err
+	}
+	c.Add(key, v)
+	return v, nil
+}
+
+// GetOrLoadContext is like GetOrLoad, but passes ctx to loader. If ctx is
+// already done on a miss, ctx.Err() is returned without calling loader, and
+// if ctx is done by the time loader returns, the loaded value is discarded
+// and ctx.Err() is returned.
+func (c *LRUGeneric[K, V]) GetOrLoadContext(
+	ctx context.Context,
+	key K,
+	loader func(ctx context.Context, key K) (V, error),
+) (value V, err error) {
+	key = c.normalize(key)
+	if v, ok, negative := c.Lookup(key); ok {
+		if negative {
+			return value, ErrNegativeEntry
+		}
+		return v, nil
+	}
+	if err = ctx.Err(); err != nil {
+		return value, err
+	}
+	v, err := loader(ctx, key)
+	if err != nil {
+		return value, err
+	}
+	if err = ctx.Err(); err != nil {
+		return value, err
+	}
+	c.Add(key, v)
+	return v, nil
+}
+
 // Add adds a value to the cache.  Returns true if an eviction occurred.
-func (c *LRU) Add(key, value interface{}) (evicted bool) {
+func (c *LRUGeneric[K, V]) Add(key K, value V) (evicted bool) {
+	return c.add(key, value, c.ttl)
+}
+
+// TryAdd adds a value to the cache like Add, additionally reporting whether
+// the value was stored. It is only ever false for a cache constructed
+// with WithRejectOnFull, when key is new and the cache is full.
+func (c *LRUGeneric[K, V]) TryAdd(key K, value V) (inserted, evicted bool) {
+	key = c.normalize(key)
+	evicted = c.Add(key, value)
+	_, inserted = c.items[key]
+	return inserted, evicted
+}
+
+// AddEx adds a value to the cache like Add, additionally reporting whether
+// key is new. Unlike TryAdd, inserted is false when the value overwrote a
+// live entry; an expired entry for key counts as absent.
+func (c *LRUGeneric[K, V]) AddEx(key K, value V) (inserted, evicted bool) {
+	key = c.normalize(key)
+	existed := c.Contains(key)
+	evicted = c.Add(key, value)
+	_, stored := c.items[key]
+	return stored && !existed, evicted
+}
+
+// AddReturningEvicted adds a value to the cache like Add, additionally
+// returning the entry evicted to make room for it, which is the same pair
+// the evict callback receives. When several entries are evicted, the oldest
+// of them is returned.
+func (c *LRUGeneric[K, V]) AddReturningEvicted(key K, value V) (evictedKey K, evictedValue V, evicted bool) {
+	victims := c.recordVictims(func() {
+		evicted = c.Add(key, value)
+	})
+	if len(victims) > 0 {
+		evictedKey, evictedValue = victims[0].Key, victims[0].Value
+	}
+	return evictedKey, evictedValue, evicted
+}
+
+// recordVictims runs f, returning the entries it evicted for capacity.
+func (c *LRUGeneric[K, V]) recordVictims(f func()) []EntryGeneric[K, V] {
+	var victims []EntryGeneric[K, V]
+	c.victims = &victims
+	defer func() {
+		c.victims = nil
+	}()
+	f()
+	return victims
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl, overriding
+// the default TTL of the cache. A non-positive ttl means the entry never
+// expires. Returns true if an eviction occurred.
+func (c *LRUGeneric[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool) {
+	return c.add(key, value, ttl)
+}
+
+// AddNegative caches the absence of a value for key for the given ttl, so
+// that GetOrLoad does not call its loader again until the entry expires. Get
+// and Peek report a negative entry as present with a nil value; Lookup tells
+// negative entries apart. Returns true if an eviction occurred.
+func (c *LRUGeneric[K, V]) AddNegative(key K, ttl time.Duration) (evicted bool) {
+	key = c.normalize(key)
+	var zero V
+	evicted = c.add(key, zero, ttl)
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*entry[K, V]).extra().negative = true
+	}
+	return evicted
+}
+
+// Lookup looks up a key's value from the cache like Get, additionally
+// reporting whether the key is cached as negative by AddNegative. A negative
+// entry is reported as (nil, true, true).
+func (c *LRUGeneric[K, V]) Lookup(key K) (value V, ok, negative bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if kv := c.lookup(key, &now); kv != nil {
+		return kv.value, true, kv.negative()
+	}
+	return
+}
+
+// add adds or updates a value with the given ttl.
+func (c *LRUGeneric[K, V]) add(key K, value V, ttl time.Duration) (evicted bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if c.admission != nil {
+		c.admission.increment(key)
+	}
+
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
-		c.evictList.MoveToFront(ent)
-		ent.Value.(*entry).value = value
-		if c.onAcquire != nil {
-			c.onAcquire(key, ent.Value.(*entry).value)
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) {
+			c.promote(ent)
+			kv.accesses++
+			c.setValue(kv, value)
+			c.emit(EventAdd, key, value)
+			if kv.ext != nil {
+				kv.ext.negative = false
+			}
+			kv.ttl = ttl
+			kv.expiresAt = deadline(&now, ttl)
+			kv.addedAt = now.get()
+			if c.onAcquire != nil {
+				c.onAcquire(key, kv.value)
+			}
+			// A costlier value may push the cache over budget
+			evicted = c.evictOverflow()
+			c.observePressure(evicted)
+			return evicted
 		}
-		return false
+		// An expired entry is evicted and replaced by a fresh one
+		c.removeElement(ent, ReasonExpired)
 	}
 
-	return c.addItem(key, value)
+	return c.addItem(key, value, ttl, &now)
+}
 
+// Get looks up a key's value from the cache. Expired entries are removed
+// and reported as absent.
+func (c *LRUGeneric[K, V]) Get(key K) (value V, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	return c.get(key, &now)
 }
 
-// Get looks up a key's value from the cache.
-func (c *LRU) Get(key interface{}) (value interface{}, ok bool) {
+// GetWithTTL looks up a key's value from the cache like Get, additionally
+// returning the time remaining until the entry expires. An entry that never
+// expires is reported with a zero ttl; a live entry with an expiry always has
+// a positive ttl, since expired entries are removed and reported as absent.
+func (c *LRUGeneric[K, V]) GetWithTTL(key K) (value V, ttl time.Duration, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	kv := c.lookup(key, &now)
+	if kv == nil {
+		return
+	}
+	if !kv.expiresAt.IsZero() {
+		ttl = kv.expiresAt.Sub(now.get())
+	}
+	return kv.value, ttl, true
+}
+
+// Access reports whether key is in the cache and, if so, promotes it like
+// Get, firing the acquire callback, without returning its value. Expired
+// entries are removed and reported as absent.
+func (c *LRUGeneric[K, V]) Access(key K) bool {
+	key = c.normalize(key)
+	now := c.now()
+	return c.lookup(key, &now) != nil
+}
+
+// get looks up a key's value, removing it if it has expired.
+func (c *LRUGeneric[K, V]) get(key K, now *lazyNow) (value V, ok bool) {
+	if kv := c.lookup(key, now); kv != nil {
+		return kv.value, true
+	}
+	return
+}
+
+// lookup finds and promotes the live entry for key, removing it if it has
+// expired. Returns nil on a miss.
+func (c *LRUGeneric[K, V]) lookup(key K, now *lazyNow) *entry[K, V] {
+	if c.admission != nil {
+		c.admission.increment(key)
+	}
 	if ent, ok := c.items[key]; ok {
-		c.evictList.MoveToFront(ent)
-		if c.onAcquire != nil {
-			c.onAcquire(key, ent.Value.(*entry).value)
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(now) {
+			c.stats.Hits++
+			kv.accesses++
+			c.promote(ent)
+			c.slide(kv, now)
+			c.emit(EventGet, key, kv.value)
+			if c.onAcquire != nil && !c.skipAcquireOnGet {
+				c.onAcquire(key, kv.value)
+			}
+			return kv
 		}
-		return ent.Value.(*entry).value, true
+		c.removeElement(ent, ReasonExpired)
 	}
-	return
+	c.stats.Misses++
+	if c.tier != nil {
+		if value, ok := c.tier.Get(key); ok {
+			c.addItem(key, value, c.ttl, now)
+			if ent, ok := c.items[key]; ok {
+				return ent.Value.(*entry[K, V])
+			}
+			// Not stored, e.g. by a full cache with rejectOnFull
+			return &entry[K, V]{key: key, value: value}
+		}
+	}
+	if c.onMiss != nil {
+		c.onMiss(key)
+	}
+	return nil
+}
+
+// Touch updates the "recently used"-ness of the key without returning its
+// value or firing the acquire callback. Returns whether the key was present;
+// an expired entry is removed and reported as absent.
+func (c *LRUGeneric[K, V]) Touch(key K) (present bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) {
+			c.promote(ent)
+			c.slide(kv, &now)
+			return true
+		}
+		c.removeElement(ent, ReasonExpired)
+	}
+	return false
+}
+
+// UpdateValue replaces the value of an existing key without updating its
+// "recently used"-ness or firing the acquire callback. Returns whether the
+// key was present; absent keys are not added.
+func (c *LRUGeneric[K, V]) UpdateValue(key K, value V) (present bool) {
+	key = c.normalize(key)
+	now := c.now()
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	kv := ent.Value.(*entry[K, V])
+	if kv.expired(&now) {
+		c.removeElement(ent, ReasonExpired)
+		return false
+	}
+	c.setValue(kv, value)
+	// A costlier value may push the cache over budget
+	c.evictOverflow()
+	return true
+}
+
+// GetMulti looks up several keys at once. Found keys are promoted in the
+// order they appear in keys, so the last found key becomes the most
+// recently used. Returns the found values by key and the missing keys in
+// their original order.
+func (c *LRUGeneric[K, V]) GetMulti(keys []K) (found map[K]V, missing []K) {
+	found = make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := c.Get(key); ok {
+			found[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+// GetOrLoadMulti looks up several keys like GetMulti, then calls loader once
+// with the missing keys, in their original order. The values it returns for
+// those keys are added to the cache in that order and merged into the
+// result; keys it leaves out are absent from the result. If loader returns
+// an error nothing is cached and the error is returned. loader is not called
+// if every key is found.
+func (c *LRUGeneric[K, V]) GetOrLoadMulti(
+	keys []K,
+	loader func(missing []K) (map[K]V, error),
+) (map[K]V, error) {
+	found, missing := c.GetMulti(keys)
+	if len(missing) == 0 {
+		return found, nil
+	}
+	loaded, err := loader(missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range missing {
+		if value, ok := loaded[key]; ok {
+			c.Add(key, value)
+			found[key] = value
+		}
+	}
+	return found, nil
+}
+
+// AddMulti adds several values at once. Since map iteration order is
+// random, so is the recency order among the added keys. Returns how many of
+// the adds caused an eviction.
+func (c *LRUGeneric[K, V]) AddMulti(items map[K]V) (evicted int) {
+	for key, value := range items {
+		if c.Add(key, value) {
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// AddMultiWithJitteredTTL adds several values at once like AddMulti, giving
+// each a TTL drawn uniformly from baseTTL-jitter to baseTTL+jitter, so that
+// entries loaded together do not all expire together. jitter is capped just
+// below baseTTL so that every TTL stays positive; a non-positive baseTTL
+// means the entries never expire. Pass a seeded source with WithRandSource
+// to make the TTLs reproducible, though which key gets which TTL still
+// follows the random map iteration order. Returns how many of the adds
+// caused an eviction.
+func (c *LRUGeneric[K, V]) AddMultiWithJitteredTTL(
+	items map[K]V,
+	baseTTL, jitter time.Duration,
+) (evicted int) {
+	if jitter >= baseTTL {
+		jitter = baseTTL - 1
+	}
+	for key, value := range items {
+		ttl := baseTTL
+		if jitter > 0 {
+			ttl += time.Duration(c.int63n(2*int64(jitter)+1)) - jitter
+		}
+		if c.add(key, value, ttl) {
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// int63n returns a random number in [0, n) from the cache's source.
+func (c *LRUGeneric[K, V]) int63n(n int64) int64 {
+	if c.rand == nil {
+		return rand.Int63n(n)
+	}
+	return c.rand.Int63n(n)
 }
 
 // Contains checks if a key is in the cache, without updating the recent-ness
-// or deleting it for being stale.
-func (c *LRU) Contains(key interface{}) (ok bool) {
-	_, ok = c.items[key]
-	return ok
+// or deleting it for being stale. Expired entries are reported as absent.
+func (c *LRUGeneric[K, V]) Contains(key K) (ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	ent, ok := c.items[key]
+	return ok && !ent.Value.(*entry[K, V]).expired(&now)
+}
+
+// ContainsOrExpire checks if a key is in the cache like Contains, but also
+// removes the entry if it has expired rather than leaving it to be reaped by
+// a later lookup. It does not update the recent-ness of the key.
+func (c *LRUGeneric[K, V]) ContainsOrExpire(key K) (ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if !ent.Value.(*entry[K, V]).expired(&now) {
+			return true
+		}
+		c.removeElement(ent, ReasonExpired)
+	}
+	return false
+}
+
+// ContainsAll reports whether every one of keys is in the cache, without
+// updating their recent-ness. It is true when no keys are given.
+func (c *LRUGeneric[K, V]) ContainsAll(keys ...K) bool {
+	for _, key := range keys {
+		if !c.Contains(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness, and if not, adds the value. An expired entry counts as absent:
+// it is removed, firing the eviction callbacks, and replaced by the value.
+// Returns whether found and whether an eviction occurred.
+func (c *LRUGeneric[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if !ent.Value.(*entry[K, V]).expired(&now) {
+			return true, false
+		}
+		c.removeElement(ent, ReasonExpired)
+	}
+	return false, c.addItem(key, value, c.ttl, &now)
+}
+
+// ReplaceOrAdd adds a value to the cache like Add, additionally returning the
+// value it replaced. existed reports whether key held a live entry, and
+// evicted whether adding a new key caused an eviction.
+func (c *LRUGeneric[K, V]) ReplaceOrAdd(key K, value V) (previous V, existed, evicted bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			previous, existed = kv.value, true
+		}
+	}
+	return previous, existed, c.Add(key, value)
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness, and if not, adds the value. An expired entry counts as absent:
+// it is removed, firing the eviction callbacks, and replaced by the value.
+// Returns the existing value if found, whether found and whether an
+// eviction occurred.
+func (c *LRUGeneric[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) {
+			return kv.value, true, false
+		}
+		c.removeElement(ent, ReasonExpired)
+	}
+	return previous, false, c.addItem(key, value, c.ttl, &now)
+}
+
+// AddIfAbsent stores value for key unless key already holds a live entry,
+// like sync.Map's LoadOrStore. If it does, the existing value is returned with
+// loaded true and its recent-ness is left unchanged; otherwise value is added
+// and returned with loaded false.
+func (c *LRUGeneric[K, V]) AddIfAbsent(key K, value V) (actual V, loaded bool) {
+	if previous, ok, _ := c.PeekOrAdd(key, value); ok {
+		return previous, true
+	}
+	return value, false
 }
 
 // Peek returns the key value (or undefined if not found) without updating
-// the "recently used"-ness of the key.
-func (c *LRU) Peek(key interface{}) (value interface{}, ok bool) {
-	var ent *list.Element
-	if ent, ok = c.items[key]; ok {
-		return ent.Value.(*entry).value, true
+// the "recently used"-ness of the key. Expired entries are reported as absent.
+func (c *LRUGeneric[K, V]) Peek(key K) (value V, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) {
+			return kv.value, true
+		}
+	}
+	return
+}
+
+// GetNoPromote looks up a key's value like Peek, without updating the
+// "recently used"-ness of the key, but fires the acquire callback on a hit
+// like Get, unless disabled by WithFireAcquireOnGet. Hits and misses are
+// counted in the stats as for Get. Expired entries are reported as absent.
+func (c *LRUGeneric[K, V]) GetNoPromote(key K) (value V, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			c.stats.Hits++
+			if c.onAcquire != nil && !c.skipAcquireOnGet {
+				c.onAcquire(key, kv.value)
+			}
+			return kv.value, true
+		}
+	}
+	c.stats.Misses++
+	return
+}
+
+// GetHint looks up a key's value like Get if promote is true, or like
+// GetNoPromote if it is false, letting callers keep one-off scans from
+// polluting the recency order while still firing the acquire callback.
+func (c *LRUGeneric[K, V]) GetHint(key K, promote bool) (value V, ok bool) {
+	if promote {
+		return c.Get(key)
 	}
-	return nil, ok
+	return c.GetNoPromote(key)
 }
 
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
-func (c *LRU) Remove(key interface{}) (present bool) {
+func (c *LRUGeneric[K, V]) Remove(key K) (present bool) {
+	key = c.normalize(key)
 	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+		c.removeElement(ent, ReasonRemoved)
 		return true
 	}
 	return false
 }
 
+// PeekAndRemove removes the provided key from the cache and returns its
+// value, if the key was contained. An expired entry is removed but reported
+// as absent.
+func (c *LRUGeneric[K, V]) PeekAndRemove(key K) (value V, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		kv := ent.Value.(*entry[K, V])
+		if kv.expired(&now) {
+			c.removeElement(ent, ReasonExpired)
+			return value, false
+		}
+		c.removeElement(ent, ReasonRemoved)
+		return kv.value, true
+	}
+	return
+}
+
 // RemoveOldest removes the oldest item from the cache.
-func (c *LRU) RemoveOldest() (key interface{}, value interface{}, ok bool) {
+func (c *LRUGeneric[K, V]) RemoveOldest() (key K, value V, ok bool) {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
-		kv := ent.Value.(*entry)
+		c.removeElement(ent, ReasonRemoved)
+		kv := ent.Value.(*entry[K, V])
+		return kv.key, kv.value, true
+	}
+	return
+}
+
+// RemoveOldestN removes up to n of the oldest entries from the cache,
+// returning them from oldest to newest. It removes everything if n is at
+// least Len.
+func (c *LRUGeneric[K, V]) RemoveOldestN(n int) (removed []EntryGeneric[K, V]) {
+	for ent := c.evictList.Back(); ent != nil && len(removed) < n; ent = c.evictList.Back() {
+		kv := ent.Value.(*entry[K, V])
+		c.removeElement(ent, ReasonRemoved)
+		removed = append(removed, EntryGeneric[K, V]{Key: kv.key, Value: kv.value})
+	}
+	return removed
+}
+
+// RemoveExpired removes every expired entry from the cache, returning how
+// many were removed.
+func (c *LRUGeneric[K, V]) RemoveExpired() (removed int) {
+	now := c.now()
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := ent.Prev()
+		if ent.Value.(*entry[K, V]).expired(&now) {
+			c.removeElement(ent, ReasonExpired)
+			removed++
+		}
+		ent = prev
+	}
+	return removed
+}
+
+// RemoveOlderThan removes every entry whose value was added more than d ago,
+// regardless of its TTL or how recently it was used, returning how many were
+// removed. Overwriting a key with Add counts as adding it again.
+func (c *LRUGeneric[K, V]) RemoveOlderThan(d time.Duration) (removed int) {
+	now := c.now()
+	cutoff := now.get().Add(-d)
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := ent.Prev()
+		if ent.Value.(*entry[K, V]).addedAt.Before(cutoff) {
+			c.removeElement(ent, ReasonExpired)
+			removed++
+		}
+		ent = prev
+	}
+	return removed
+}
+
+// RemoveFunc removes every entry for which match returns true, returning how
+// many were removed. match is called once per entry, from oldest to newest,
+// and must not modify the cache.
+func (c *LRUGeneric[K, V]) RemoveFunc(match func(key K, value V) bool) (removed int) {
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := ent.Prev()
+		kv := ent.Value.(*entry[K, V])
+		if match(kv.key, kv.value) {
+			c.removeElement(ent, ReasonRemoved)
+			removed++
+		}
+		ent = prev
+	}
+	return removed
+}
+
+// PopNewest removes the newest item from the cache and returns it.
+func (c *LRUGeneric[K, V]) PopNewest() (key K, value V, ok bool) {
+	ent := c.evictList.Front()
+	if ent != nil {
+		c.removeElement(ent, ReasonRemoved)
+		kv := ent.Value.(*entry[K, V])
 		return kv.key, kv.value, true
 	}
-	return nil, nil, false
+	return
 }
 
 // GetOldest returns the oldest entry
-func (c *LRU) GetOldest() (key interface{}, value interface{}, ok bool) {
+func (c *LRUGeneric[K, V]) GetOldest() (key K, value V, ok bool) {
 	ent := c.evictList.Back()
 	if ent != nil {
-		kv := ent.Value.(*entry)
+		kv := ent.Value.(*entry[K, V])
 		return kv.key, kv.value, true
 	}
-	return nil, nil, false
+	return
+}
+
+// PeekNewest returns the newest entry without updating the "recently
+// used"-ness of the key.
+func (c *LRUGeneric[K, V]) PeekNewest() (key K, value V, ok bool) {
+	ent := c.evictList.Front()
+	if ent != nil {
+		kv := ent.Value.(*entry[K, V])
+		return kv.key, kv.value, true
+	}
+	return
+}
+
+// Position returns how many entries are more recently used than key, so 0
+// is the newest entry, without updating the recent-ness of any key. Like
+// the other methods walking the cache, it skips expired entries. It is meant
+// for diagnostics.
+func (c *LRUGeneric[K, V]) Position(key K) (pos int, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; !ok || ent.Value.(*entry[K, V]).expired(&now) {
+		return 0, false
+	}
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		kv := ent.Value.(*entry[K, V])
+		if kv.key == key {
+			return pos, true
+		}
+		if !kv.expired(&now) {
+			pos++
+		}
+	}
+	return 0, false
+}
+
+// AccessCount returns how many times Get or Add found key in the cache since
+// it was added, without updating its recent-ness.
+func (c *LRUGeneric[K, V]) AccessCount(key K) (count uint64, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			return kv.accesses, true
+		}
+	}
+	return 0, false
+}
+
+// HotKeys returns up to n keys with the highest access counts, most accessed
+// first. Keys with equal counts are ordered from newest to oldest. Expired
+// entries are skipped.
+func (c *LRUGeneric[K, V]) HotKeys(n int) []K {
+	if n <= 0 {
+		return nil
+	}
+	now := c.now()
+	entries := make([]*entry[K, V], 0, len(c.items))
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			entries = append(entries, kv)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].accesses > entries[j].accesses
+	})
+	if n > len(entries) {
+		n = len(entries)
+	}
+	keys := make([]K, n)
+	for i := range keys {
+		keys[i] = entries[i].key
+	}
+	return keys
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
-func (c *LRU) Keys() []interface{} {
-	keys := make([]interface{}, len(c.items))
-	i := 0
+// Expired entries, including those invalidated by BumpEpoch, are skipped even
+// though they count towards Len until they are removed.
+func (c *LRUGeneric[K, V]) Keys() []K {
+	now := c.now()
+	keys := make([]K, 0, len(c.items))
 	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
-		keys[i] = ent.Value.(*entry).key
-		i++
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			keys = append(keys, kv.key)
+		}
 	}
 	return keys
 }
 
+// KeysMatching returns the keys in the cache for which pred returns true,
+// from oldest to newest, skipping expired entries like Keys. pred must not
+// modify the cache.
+func (c *LRUGeneric[K, V]) KeysMatching(pred func(key K) bool) []K {
+	now := c.now()
+	var keys []K
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) && pred(kv.key) {
+			keys = append(keys, kv.key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest,
+// in the same order as Keys. It does not update the recent-ness of any key.
+func (c *LRUGeneric[K, V]) Values() []V {
+	now := c.now()
+	values := make([]V, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			values = append(values, kv.value)
+		}
+	}
+	return values
+}
+
+// Range calls f for each entry in the cache, from oldest to newest, until f
+// returns false, skipping expired entries like Keys. It does not update the
+// recent-ness of any key. f may Remove the key it was called with, but must
+// not otherwise modify the cache.
+func (c *LRUGeneric[K, V]) Range(f func(key K, value V) bool) {
+	now := c.now()
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := ent.Prev()
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) && !f(kv.key, kv.value) {
+			return
+		}
+		ent = prev
+	}
+}
+
+// RangeNewest calls f for each entry in the cache, from newest to oldest, so
+// the most recently used key comes first, until f returns false. It is
+// otherwise like Range.
+func (c *LRUGeneric[K, V]) RangeNewest(f func(key K, value V) bool) {
+	now := c.now()
+	for ent := c.evictList.Front(); ent != nil; {
+		next := ent.Next()
+		kv := ent.Value.(*entry[K, V])
+		if !kv.expired(&now) && !f(kv.key, kv.value) {
+			return
+		}
+		ent = next
+	}
+}
+
+// Clone returns an independent copy of the cache with the same capacity,
+// callbacks, entries and recency order. The values themselves are shared,
+// not copied.
+func (c *LRUGeneric[K, V]) Clone() *LRUGeneric[K, V] {
+	clone := *c
+	clone.evictList.Init()
+	clone.items = make(map[K]*list.Element, len(c.items))
+	clone.evictErrs = nil
+	clone.victims = nil
+	clone.events = nil
+	clone.refs = nil
+	clone.deferred = nil
+	if c.admission != nil {
+		clone.admission = c.admission.clone()
+	}
+	if c.ghosts != nil {
+		clone.ghosts = c.ghosts.clone()
+	}
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		kv := *ent.Value.(*entry[K, V])
+		if kv.ext != nil {
+			ext := *kv.ext
+			kv.ext = &ext
+		}
+		clone.items[kv.key] = clone.evictList.PushFront(&kv)
+	}
+	return &clone
+}
+
+// ToMap returns a new map holding every live key and value in the cache,
+// without updating the recent-ness of any key. It is O(n) and allocates
+// the whole map; changes to it do not affect the cache.
+func (c *LRUGeneric[K, V]) ToMap() map[K]V {
+	now := c.now()
+	m := make(map[K]V, len(c.items))
+	for key, ent := range c.items {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			m[key] = kv.value
+		}
+	}
+	return m
+}
+
+// Snapshot returns the live entries of the cache, from newest to oldest,
+// along with their remaining TTL, without updating the recent-ness of any
+// key. Expired entries, including those invalidated by BumpEpoch, and
+// negative entries are left out.
+func (c *LRUGeneric[K, V]) Snapshot() []EntryGeneric[K, V] {
+	now := c.now()
+	entries := make([]EntryGeneric[K, V], 0, len(c.items))
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		kv := ent.Value.(*entry[K, V])
+		if kv.negative() || kv.expired(&now) {
+			continue
+		}
+		e := EntryGeneric[K, V]{Key: kv.key, Value: kv.value}
+		if !kv.expiresAt.IsZero() {
+			e.TTL = kv.expiresAt.Sub(now.get())
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Restore purges the cache and refills it from entries ordered newest to
+// oldest, as returned by Snapshot. Entries with a TTL expire after it, and
+// the others get the default TTL of the cache, as with Add. If there are
+// more entries than the cache can hold, the oldest ones are dropped.
+func (c *LRUGeneric[K, V]) Restore(entries []EntryGeneric[K, V]) {
+	c.Purge()
+	if c.size > 0 && len(entries) > c.size {
+		entries = entries[:c.size]
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.TTL > 0 {
+			c.add(e.Key, e.Value, e.TTL)
+		} else {
+			c.Add(e.Key, e.Value)
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
-func (c *LRU) Len() int {
+func (c *LRUGeneric[K, V]) Len() int {
 	return c.evictList.Len()
 }
 
-// removeOldest removes the oldest item from the cache.
-func (c *LRU) removeOldest() {
-	ent := c.evictList.Back()
-	if ent != nil {
-		c.removeElement(ent)
+// CheckConsistency verifies that the map and list of the cache are in sync:
+// every list element is in the map under its own key, both hold Len entries,
+// and the running cost and size totals match the entries. It is O(n) and
+// meant to be called from tests after a sequence of operations.
+func (c *LRUGeneric[K, V]) CheckConsistency() error {
+	return c.checkInvariants()
+}
+
+// checkInvariants returns an error describing the first broken invariant.
+func (c *LRUGeneric[K, V]) checkInvariants() error {
+	if len(c.items) != c.evictList.Len() {
+		return fmt.Errorf("simplelru: map holds %d entries, list holds %d",
+			len(c.items), c.evictList.Len())
+	}
+	var cost, bytes int64
+	n := 0
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		kv := ent.Value.(*entry[K, V])
+		if c.items[kv.key] != ent {
+			return fmt.Errorf("simplelru: list element for key %v is not in the map", kv.key)
+		}
+		cost += kv.cost
+		bytes += kv.bytes
+		n++
+	}
+	if n != c.Len() {
+		return fmt.Errorf("simplelru: list links %d entries, Len is %d", n, c.Len())
+	}
+	if cost != c.currentCost {
+		return fmt.Errorf("simplelru: entries cost %d, total is %d", cost, c.currentCost)
+	}
+	if bytes != c.currentBytes {
+		return fmt.Errorf("simplelru: entries size %d, total is %d", bytes, c.currentBytes)
+	}
+	return nil
+}
+
+// Cap returns the maximum number of items the cache holds. It is zero for
+// caches bounded only by cost, or unbounded by WithUnbounded.
+func (c *LRUGeneric[K, V]) Cap() int {
+	return c.size
+}
+
+// Resize changes the cache size, evicting the oldest entries if the cache
+// holds more than size entries. Returns the number of evicted entries.
+// A non-positive size leaves the cache unchanged.
+func (c *LRUGeneric[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	defer c.checkHighWater(!c.highWaterTripped)
+	if c.frozen {
+		c.size = size
+		return 0
+	}
+	diff := c.Len() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for evicted < diff && c.removeVictim() {
+		evicted++
+	}
+	c.size = size
+	return evicted
+}
+
+// Pin protects the entry for key from capacity-driven eviction until Unpin is
+// called; the oldest unpinned entry is evicted instead. If every entry is
+// pinned the cache grows beyond its capacity, and is trimmed back by later
+// adds once entries are unpinned. Pinned entries can still be removed
+// explicitly, and still expire. Returns whether the key was present.
+func (c *LRUGeneric[K, V]) Pin(key K) bool {
+	return c.setPinned(key, true)
+}
+
+// Unpin makes the entry for key evictable again. Returns whether the key was
+// present.
+func (c *LRUGeneric[K, V]) Unpin(key K) bool {
+	return c.setPinned(key, false)
+}
+
+func (c *LRUGeneric[K, V]) setPinned(key K, pinned bool) bool {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			kv.extra().pinned = pinned
+			return true
+		}
+	}
+	return false
+}
+
+// Freeze suspends capacity-driven eviction until Unfreeze is called, so that
+// no entry disappears without being removed explicitly. While frozen, adding
+// new keys grows the cache beyond its capacity and Resize defers any trimming
+// to Unfreeze. Remove, Purge and expiry work as usual.
+func (c *LRUGeneric[K, V]) Freeze() {
+	c.frozen = true
+}
+
+// Unfreeze resumes capacity-driven eviction, evicting the entries the cache
+// has grown by while frozen. Returns the number of evicted entries.
+func (c *LRUGeneric[K, V]) Unfreeze() (evicted int) {
+	c.frozen = false
+	for c.overCapacity() && c.removeVictim() {
+		evicted++
+	}
+	return evicted
+}
+
+// Cost returns the total cost of the entries in the cache. It is always zero
+// for caches not constructed with NewLRUWithCost.
+func (c *LRUGeneric[K, V]) Cost() int64 {
+	return c.currentCost
+}
+
+// Bytes returns the total size of the entries in the cache as estimated by
+// its SizeOfFunc, which NewLRUWithLimits bounds by maxBytes. It is zero for a
+// cache without a SizeOfFunc.
+func (c *LRUGeneric[K, V]) Bytes() int64 {
+	return c.currentBytes
+}
+
+// ApproxBytes returns the estimated memory footprint of the entries in the
+// cache, as the running total of the SizeOfFunc set with WithSizeOf, or -1 if
+// there is none.
+func (c *LRUGeneric[K, V]) ApproxBytes() int64 {
+	if c.sizeOf == nil {
+		return -1
 	}
+	return c.currentBytes
+}
+
+// Stats returns a copy of the cache's hit, miss, eviction and insertion
+// counters.
+func (c *LRUGeneric[K, V]) Stats() Stats {
+	return c.stats
+}
+
+// ResetStats zeroes the cache's counters.
+func (c *LRUGeneric[K, V]) ResetStats() {
+	c.stats = Stats{}
+}
+
+// StatsAndReset returns the cache's counters like Stats and zeroes them, so
+// that the counters of consecutive intervals add up to the overall totals.
+func (c *LRUGeneric[K, V]) StatsAndReset() Stats {
+	stats := c.stats
+	c.stats = Stats{}
+	return stats
+}
+
+// NormalizeKey returns the key the cache stores key under, which differs
+// from key only when a KeyNormalizer is set with WithKeyNormalizer. It only
+// reads the normalizer, so a locking wrapper can call it unlocked.
+func (c *LRUGeneric[K, V]) NormalizeKey(key K) K {
+	return c.normalize(key)
+}
+
+// normalize returns the form of key used in the items map.
+func (c *LRUGeneric[K, V]) normalize(key K) K {
+	if c.normalizer == nil {
+		return key
+	}
+	return c.normalizer(key)
+}
+
+// now returns a lazyNow reading the cache's clock.
+func (c *LRUGeneric[K, V]) now() lazyNow {
+	return lazyNow{clock: c.clock, epoch: c.epoch}
+}
+
+// slide pushes back the deadline of an accessed entry when using
+// SlidingExpiration.
+func (c *LRUGeneric[K, V]) slide(kv *entry[K, V], now *lazyNow) {
+	if c.expiration == SlidingExpiration {
+		kv.expiresAt = deadline(now, kv.ttl)
+	}
+}
+
+// promote moves an entry to the front of the list, unless the cache keeps
+// insertion order.
+func (c *LRUGeneric[K, V]) promote(ent *list.Element) {
+	if !c.insertionOrder {
+		c.evictList.MoveToFront(ent)
+	}
+}
+
+// removeVictim removes the entry chosen by the eviction policy, by default
+// the oldest, from the cache to make room. Returns false if there was no
+// evictable entry.
+func (c *LRUGeneric[K, V]) removeVictim() bool {
+	ent := c.victim()
+	if ent == nil {
+		return false
+	}
+	c.removeElement(ent, ReasonCapacity)
+	return true
+}
+
+// victim returns the element the eviction policy would evict next, or nil if
+// every entry is pinned or the cache is empty. A pinned entry chosen by the
+// policy is passed over for the oldest unpinned one.
+func (c *LRUGeneric[K, V]) victim() *list.Element {
+	if c.policy != nil && c.evictList.Len() > 0 {
+		if ent, ok := c.items[c.policy.Victim(c)]; ok && !ent.Value.(*entry[K, V]).pinned() {
+			return ent
+		}
+	}
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if !ent.Value.(*entry[K, V]).pinned() {
+			return ent
+		}
+	}
+	return nil
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *LRU) removeElement(e *list.Element) {
+func (c *LRUGeneric[K, V]) removeElement(e *list.Element, reason EvictReason) {
 	c.evictList.Remove(e)
-	kv := e.Value.(*entry)
+	kv := e.Value.(*entry[K, V])
 	delete(c.items, kv.key)
-	if c.onEvict != nil {
-		c.onEvict(kv.key, kv.value)
+	c.currentCost -= kv.cost
+	c.currentBytes -= kv.bytes
+	c.stats.Evictions++
+	if c.highWaterTripped && !c.aboveHighWater() {
+		c.highWaterTripped = false
+	}
+	if c.victims != nil && reason == ReasonCapacity {
+		*c.victims = append(*c.victims, EntryGeneric[K, V]{Key: kv.key, Value: kv.value})
+	}
+	if c.ghosts != nil && reason == ReasonCapacity {
+		c.ghosts.push(kv.key)
 	}
+	if c.tier != nil && reason == ReasonCapacity && !kv.negative() {
+		c.tier.Set(kv.key, kv.value)
+	}
+	c.evicted(kv, reason)
 }
 
 // addItem adds an item. Should only be used if the item does not exist already.
-func (c *LRU) addItem(key, value interface{}) (evict bool) {
-	ent := &entry{key, value}
+// With rejectOnFull the item is dropped if it does not fit.
+func (c *LRUGeneric[K, V]) addItem(key K, value V, ttl time.Duration, now *lazyNow) (evict bool) {
+	return c.insertEntry(c.newEntry(key, value, ttl, now))
+}
+
+// newEntry returns an entry for a value added now, with its cost and size.
+func (c *LRUGeneric[K, V]) newEntry(key K, value V, ttl time.Duration, now *lazyNow) *entry[K, V] {
+	ent := &entry[K, V]{key: key, value: value, ttl: ttl, expiresAt: deadline(now, ttl), addedAt: now.get(), epoch: now.epoch}
+	if c.costFunc != nil {
+		ent.cost = c.costFunc(key, value)
+	}
+	if c.sizeOf != nil {
+		ent.bytes = c.sizeOf(key, value)
+	}
+	return ent
+}
+
+// insertEntry adds ent like addItem.
+func (c *LRUGeneric[K, V]) insertEntry(ent *entry[K, V]) (evict bool) {
+	defer func() {
+		c.observePressure(evict)
+	}()
+	key, value := ent.key, ent.value
+	// Evictions made for room must not re-arm the high water mark
+	armed := !c.highWaterTripped
+	if c.rejectOnFull && !c.fits(ent) {
+		return false
+	}
+	if c.admission != nil && !c.frozen && !c.fits(ent) && !c.admit(key) {
+		return false
+	}
+	// Make room before inserting, so the policy cannot pick the new entry
+	pinned := false
+	if !c.frozen && !c.fits(ent) {
+		// Past any grace, evict in a batch all the way down to size
+		for c.evictList.Len() > 0 &&
+			(!c.fits(ent) || (c.size > 0 && c.evictList.Len() >= c.size)) {
+			if !c.removeVictim() {
+				// Every entry is pinned, so grow rather than evict the new one
+				pinned = true
+				break
+			}
+			evict = true
+		}
+	}
+	c.currentCost += ent.cost
+	c.currentBytes += ent.bytes
+	if c.items == nil {
+		c.items = make(map[K]*list.Element)
+	}
 	elem := c.evictList.PushFront(ent)
 	c.items[key] = elem
+	c.stats.Insertions++
+	if c.ghosts != nil {
+		c.ghosts.observe(key)
+	}
+	c.emit(EventAdd, key, value)
 	if c.onAcquire != nil {
-		c.onAcquire(key, elem.Value.(*entry).value)
+		c.onAcquire(key, elem.Value.(*entry[K, V]).value)
+	}
+	if !pinned {
+		evict = c.evictOverflow() || evict
+	}
+	c.checkHighWater(armed)
+	return evict
+}
+
+// setValue replaces the value of an existing entry, keeping the running cost
+// and size totals up to date.
+func (c *LRUGeneric[K, V]) setValue(kv *entry[K, V], value V) {
+	if c.evictOnReplace {
+		c.replaced(kv, value)
+	}
+	kv.value = value
+	if c.costFunc != nil {
+		cost := c.costFunc(kv.key, value)
+		c.currentCost += cost - kv.cost
+		kv.cost = cost
+	}
+	if c.sizeOf != nil {
+		bytes := c.sizeOf(kv.key, value)
+		c.currentBytes += bytes - kv.bytes
+		kv.bytes = bytes
+	}
+}
+
+// fits reports whether ent can be added without exceeding the capacity of
+// the cache.
+func (c *LRUGeneric[K, V]) fits(ent *entry[K, V]) bool {
+	if c.size > 0 && c.evictList.Len() >= c.limit() {
+		return false
+	}
+	if c.maxBytes > 0 && c.currentBytes+ent.bytes > c.maxBytes {
+		return false
+	}
+	return c.costFunc == nil || c.currentCost+ent.cost <= c.maxCost
+}
+
+// limit returns how many entries the cache may hold before evicting, which
+// is its size plus any grace set by WithOverflowGrace.
+func (c *LRUGeneric[K, V]) limit() int {
+	return c.size + int(float64(c.size)*c.grace)
+}
+
+// overCapacity reports whether the cache holds more entries, or more total
+// cost or size, than it is allowed to.
+func (c *LRUGeneric[K, V]) overCapacity() bool {
+	if c.size > 0 && c.evictList.Len() > c.limit() {
+		return true
+	}
+	if c.maxBytes > 0 && c.currentBytes > c.maxBytes {
+		return true
+	}
+	return c.costFunc != nil && c.currentCost > c.maxCost
+}
+
+// evictOverflow removes the oldest entries until the cache is within its
+// capacity, returning whether anything was evicted.
+func (c *LRUGeneric[K, V]) evictOverflow() (evict bool) {
+	if c.frozen {
+		return false
 	}
-	evict = c.evictList.Len() > c.size
-	// Verify size not exceeded
-	if evict {
-		c.removeOldest()
+	for c.overCapacity() && c.removeVictim() {
+		evict = true
 	}
 	return evict
 }
@@ -0,0 +1,131 @@
+package simplelru
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// WithAdmissionFilter enables TinyLFU admission control. The cache keeps a
+// small count-min sketch of how often keys are looked up or added, and once
+// it is full a new key is only added if it has been requested more often
+// than the entry it would evict. Otherwise the new value is dropped, so a
+// burst of one-off keys cannot flush out a frequently used working set.
+func WithAdmissionFilter() Option {
+	return func(c *LRU) error {
+		c.admission = newSketch(c.size)
+		return nil
+	}
+}
+
+// admit reports whether key is requested more often than the next victim.
+func (c *LRUGeneric[K, V]) admit(key K) bool {
+	victim := c.victim()
+	if victim == nil {
+		return true
+	}
+	return c.admission.estimate(key) > c.admission.estimate(victim.Value.(*entry[K, V]).key)
+}
+
+const (
+	sketchDepth   = 4
+	sketchMaxFreq = 15
+)
+
+// sketch is a count-min sketch of key frequencies. Counters saturate at
+// sketchMaxFreq and are halved periodically, so that old popularity fades.
+type sketch struct {
+	rows    [sketchDepth][]uint8
+	mask    uint64
+	added   int
+	resetAt int
+}
+
+// newSketch creates a sketch suited to a cache holding about size entries.
+func newSketch(size int) *sketch {
+	width := 256
+	for width < size {
+		width <<= 1
+	}
+	s := &sketch{mask: uint64(width - 1), resetAt: 10 * width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) clone() *sketch {
+	clone := *s
+	for i := range s.rows {
+		clone.rows[i] = append([]uint8(nil), s.rows[i]...)
+	}
+	return &clone
+}
+
+// index returns the counter for hash h in row i, using double hashing.
+func (s *sketch) index(h uint64, i int) uint64 {
+	return (h + uint64(i)*(h>>32|1)) & s.mask
+}
+
+func (s *sketch) increment(key interface{}) {
+	h := hashKey(key)
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < sketchMaxFreq {
+			s.rows[i][idx]++
+		}
+	}
+	s.added++
+	if s.added >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *sketch) estimate(key interface{}) uint8 {
+	h := hashKey(key)
+	min := uint8(sketchMaxFreq)
+	for i := range s.rows {
+		if n := s.rows[i][s.index(h, i)]; n < min {
+			min = n
+		}
+	}
+	return min
+}
+
+// reset halves every counter.
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.added /= 2
+}
+
+// hashKey hashes common key types directly, and any other key through its
+// fmt-printed form.
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case int:
+		return mix(uint64(k))
+	case int64:
+		return mix(uint64(k))
+	case uint64:
+		return mix(k)
+	case string:
+		h := fnv.New64a()
+		h.Write([]byte(k))
+		return h.Sum64()
+	}
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	return h.Sum64()
+}
+
+// mix is the splitmix64 finalizer, spreading integer keys over all bits.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,74 @@
+package simplelru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test that one-off keys cannot displace a frequently used working set
+func TestLRU_AdmissionFilter(t *testing.T) {
+	l, err := NewLRUWithOptions(4, WithAdmissionFilter())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		for j := 0; j < 3; j++ {
+			l.Get(i)
+		}
+	}
+	for i := 100; i < 200; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 4; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("hot key %d should have been kept", i)
+		}
+	}
+
+	// A key requested often enough is eventually admitted
+	for j := 0; j < 8; j++ {
+		l.Get(500)
+	}
+	if l.Add(500, 500); !l.Contains(500) {
+		t.Errorf("500 should have been admitted")
+	}
+	if l.Len() != 4 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
+
+func benchmarkZipf(b *testing.B, opts ...Option) {
+	l, err := NewLRUWithOptions(1024, opts...)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, 1<<16)
+	trace := make([]uint64, b.N)
+	for i := range trace {
+		trace[i] = z.Uint64()
+	}
+
+	b.ResetTimer()
+
+	var hit, miss int
+	for _, k := range trace {
+		if _, ok := l.Get(k); ok {
+			hit++
+		} else {
+			miss++
+			l.Add(k, k)
+		}
+	}
+	b.ReportMetric(float64(hit)/float64(hit+miss), "hit-ratio")
+}
+
+func BenchmarkLRU_Zipf(b *testing.B) {
+	benchmarkZipf(b)
+}
+
+func BenchmarkLRU_ZipfAdmission(b *testing.B) {
+	benchmarkZipf(b, WithAdmissionFilter())
+}
@@ -0,0 +1,54 @@
+package simplelru
+
+// WriteBackCallback is used to get a callback when an entry that was
+// modified since it was loaded, as marked by AddDirty, leaves the cache.
+type WriteBackCallback func(key interface{}, value interface{})
+
+// WithWriteBackCallback sets the callback fired when a dirty entry leaves
+// the cache for any reason, in addition to the evict callbacks. It is not
+// fired for entries cleared by MarkClean.
+func WithWriteBackCallback(onWriteBack WriteBackCallback) Option {
+	return func(c *LRU) error {
+		c.onWriteBack = onWriteBack
+		return nil
+	}
+}
+
+// AddDirty adds a value to the cache like Add, marking the entry as modified
+// so that the write-back callback fires when it leaves the cache. The entry
+// stays dirty when later updated by Add, until MarkClean is called.
+func (c *LRUGeneric[K, V]) AddDirty(key K, value V) (evicted bool) {
+	key = c.normalize(key)
+	evicted = c.Add(key, value)
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*entry[K, V]).extra().dirty = true
+	}
+	return evicted
+}
+
+// MarkClean clears the dirty mark of a key, for instance once its value has
+// been written back. Returns false if the key is not in the cache.
+func (c *LRUGeneric[K, V]) MarkClean(key K) bool {
+	key = c.normalize(key)
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); kv.ext != nil {
+			kv.ext.dirty = false
+		}
+		return true
+	}
+	return false
+}
+
+// DirtyKeys returns the keys of the dirty entries in the cache, from oldest
+// to newest, without updating their recent-ness. Unlike Keys, it includes
+// expired entries, including those invalidated by BumpEpoch, since their
+// write-back is still pending until they are removed.
+func (c *LRUGeneric[K, V]) DirtyKeys() []K {
+	var keys []K
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if kv := ent.Value.(*entry[K, V]); kv.dirty() {
+			keys = append(keys, kv.key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,49 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that only dirty entries are written back when evicted
+func TestLRU_WriteBack(t *testing.T) {
+	written := make(map[interface{}]interface{})
+	evicted := 0
+	l, err := NewLRUWithOptions(3,
+		WithWriteBackCallback(func(k, v interface{}) { written[k] = v }),
+		WithEvictCallback(func(k, v interface{}) { evicted++ }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddDirty(1, "one")
+	l.Add(2, "two")
+	l.AddDirty(3, "three")
+	l.Add(1, "uno") // still dirty after an update
+	if !reflect.DeepEqual(l.DirtyKeys(), []interface{}{3, 1}) {
+		t.Fatalf("bad dirty keys: %v", l.DirtyKeys())
+	}
+
+	// 2 is clean, so it is evicted without a write-back
+	l.Add(4, "four")
+	if len(written) != 0 || evicted != 1 {
+		t.Fatalf("clean entries should not be written back: %v", written)
+	}
+
+	// 3 is dirty
+	l.Add(5, "five")
+	if !reflect.DeepEqual(written, map[interface{}]interface{}{3: "three"}) || evicted != 2 {
+		t.Fatalf("dirty entry should be written back: %v", written)
+	}
+
+	if !l.MarkClean(1) || l.MarkClean(3) {
+		t.Fatalf("MarkClean should report whether the key is present")
+	}
+	l.Purge()
+	if len(written) != 1 || evicted != 5 {
+		t.Fatalf("entries marked clean should not be written back: %v", written)
+	}
+	if keys := l.DirtyKeys(); len(keys) != 0 {
+		t.Fatalf("bad dirty keys: %v", keys)
+	}
+}
@@ -0,0 +1,14 @@
+package simplelru
+
+// BumpEpoch logically invalidates every entry in O(1), for instance after a
+// configuration reload. Entries added before the bump are treated as
+// expired from then on: Get, Peek, Contains and the other lookups report
+// them as absent, and those that reap expired entries, such as Get, remove
+// them with ReasonExpired. Keys, Values, Range and the other methods that
+// walk the cache skip them, and Snapshot, MarshalJSON and WriteTo leave them
+// out, so they do not come back through a round trip. RemoveExpired sweeps
+// them all at once; until then they still count towards Len. Entries added
+// after the bump are unaffected.
+func (c *LRUGeneric[K, V]) BumpEpoch() {
+	c.epoch++
+}
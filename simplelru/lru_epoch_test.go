@@ -0,0 +1,83 @@
+package simplelru
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// Test that BumpEpoch hides older entries while newer ones stay valid
+func TestLRU_BumpEpoch(t *testing.T) {
+	var reasons []EvictReason
+	l, err := NewLRUWithOptions(8,
+		WithEvictReasonCallback(func(k, v interface{}, reason EvictReason) {
+			reasons = append(reasons, reason)
+		}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.BumpEpoch()
+	l.Add(4, 4)
+
+	if _, ok := l.Get(0); ok {
+		t.Fatalf("Get should not find 0 from an older epoch")
+	}
+	if _, ok := l.Peek(1); ok {
+		t.Fatalf("Peek should not find 1 from an older epoch")
+	}
+	if l.Contains(2) {
+		t.Fatalf("Contains should not find 2 from an older epoch")
+	}
+	if v, ok := l.Get(4); !ok || v != 4 {
+		t.Fatalf("4 should still be valid: %v, %v", v, ok)
+	}
+
+	// Get removes the stale entry; Peek and Contains leave it in place
+	if l.Len() != 4 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{4}) || !reflect.DeepEqual(l.Values(), []interface{}{4}) {
+		t.Fatalf("stale entries should not be listed: %v, %v", l.Keys(), l.Values())
+	}
+	if _, ok := l.Position(1); ok || len(l.HotKeys(8)) != 1 {
+		t.Fatalf("stale entries should not be walked: %v", l.HotKeys(8))
+	}
+
+	// A round trip does not bring stale entries back
+	var buf bytes.Buffer
+	if _, err := l.WriteTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, err := NewLRUWithEvict(8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := r.ReadFrom(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(r.Keys(), []interface{}{4}) || r.Len() != 1 {
+		t.Fatalf("only live entries should be restored: %v", r.Keys())
+	}
+	if n := l.RemoveExpired(); n != 3 || l.Len() != 1 {
+		t.Fatalf("bad sweep: %v, %v", n, l.Len())
+	}
+	for _, reason := range reasons {
+		if reason != ReasonExpired {
+			t.Fatalf("bad reason: %v", reason)
+		}
+	}
+
+	// Re-adding a key from an older epoch inserts it afresh
+	l.Add(0, 10)
+	if v, ok := l.Get(0); !ok || v != 10 {
+		t.Fatalf("0 should be valid again: %v, %v", v, ok)
+	}
+	l.BumpEpoch()
+	if l.Contains(0) || l.Contains(4) {
+		t.Fatalf("a second bump should hide every entry")
+	}
+}
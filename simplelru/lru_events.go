@@ -0,0 +1,97 @@
+package simplelru
+
+import "errors"
+
+// EventType identifies what happened to an entry in an Event.
+type EventType int
+
+const (
+	// EventAdd means a value was added or overwritten.
+	EventAdd EventType = iota
+	// EventGet means a lookup found a live entry.
+	EventGet
+	// EventEvict means an entry was evicted, expired or purged.
+	EventEvict
+	// EventRemove means an entry was removed explicitly.
+	EventRemove
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventAdd:
+		return "add"
+	case EventGet:
+		return "get"
+	case EventEvict:
+		return "evict"
+	case EventRemove:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// Event describes a change to, or lookup of, an entry in a cache.
+type Event struct {
+	Type  EventType
+	Key   interface{}
+	Value interface{}
+}
+
+// defaultEventBuffer is the capacity of the Events channel unless set with
+// WithEventBuffer.
+const defaultEventBuffer = 128
+
+// WithEventBuffer sets the capacity of the channel returned by Events. Zero
+// selects the default of 128.
+func WithEventBuffer(size int) Option {
+	return func(c *LRU) error {
+		if size < 0 {
+			return errors.New("Must provide a non-negative event buffer size")
+		}
+		c.eventBuffer = size
+		return nil
+	}
+}
+
+// Events returns a channel that receives an Event for every add, lookup hit,
+// eviction and removal, creating it on first use. Events are sent without
+// blocking: when the channel's buffer is full they are dropped and counted by
+// DroppedEvents. The same channel is returned until CloseEvents is called.
+func (c *LRUGeneric[K, V]) Events() <-chan Event {
+	if c.events == nil {
+		size := defaultEventBuffer
+		if c.eventBuffer > 0 {
+			size = c.eventBuffer
+		}
+		c.events = make(chan Event, size)
+	}
+	return c.events
+}
+
+// CloseEvents closes the channel returned by Events and stops sending
+// events. It is a no-op if Events was never called.
+func (c *LRUGeneric[K, V]) CloseEvents() {
+	if c.events != nil {
+		close(c.events)
+		c.events = nil
+	}
+}
+
+// DroppedEvents returns how many events were dropped because the channel
+// returned by Events was full.
+func (c *LRUGeneric[K, V]) DroppedEvents() uint64 {
+	return c.droppedEvents
+}
+
+// emit sends an event to the Events channel, if any, without blocking.
+func (c *LRUGeneric[K, V]) emit(t EventType, key K, value V) {
+	if c.events == nil {
+		return
+	}
+	select {
+	case c.events <- Event{Type: t, Key: key, Value: value}:
+	default:
+		c.droppedEvents++
+	}
+}
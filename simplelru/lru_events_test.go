@@ -0,0 +1,67 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that cache operations are reported on the Events channel
+func TestLRU_Events(t *testing.T) {
+	l, err := NewLRUWithOptions(2, WithEventBuffer(16))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Nothing is sent before Events is called
+	l.Add(0, 0)
+	l.Remove(0)
+
+	events := l.Events()
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Peek(2)
+	l.Add(3, 3) // evicts 2
+	l.Remove(1)
+	l.CloseEvents()
+	l.Add(4, 4)
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	want := []Event{
+		{EventAdd, 1, 1},
+		{EventAdd, 2, 2},
+		{EventGet, 1, 1},
+		{EventEvict, 2, 2},
+		{EventAdd, 3, 3},
+		{EventRemove, 1, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad events: %v", got)
+	}
+}
+
+// Test that a full Events channel drops events instead of blocking
+func TestLRU_EventsDropped(t *testing.T) {
+	l, err := NewLRUWithOptions(8, WithEventBuffer(2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	events := l.Events()
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	if len(events) != 2 || l.DroppedEvents() != 3 {
+		t.Errorf("bad: %v, %v", len(events), l.DroppedEvents())
+	}
+	if e := <-events; e.Type != EventAdd || e.Key != 0 {
+		t.Errorf("bad event: %v", e)
+	}
+
+	if _, err := NewLRUWithOptions(8, WithEventBuffer(-1)); err == nil {
+		t.Errorf("negative buffer should fail")
+	}
+}
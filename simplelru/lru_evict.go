@@ -0,0 +1,202 @@
+package simplelru
+
+import "strings"
+
+// EvictReason describes why an entry left the cache.
+type EvictReason int
+
+const (
+	// ReasonCapacity means the entry was evicted to make room.
+	ReasonCapacity EvictReason = iota
+	// ReasonRemoved means the entry was explicitly removed, for instance by
+	// Remove or RemoveOldest.
+	ReasonRemoved
+	// ReasonPurged means the entry was cleared by Purge.
+	ReasonPurged
+	// ReasonExpired means the entry's TTL had passed.
+	ReasonExpired
+	// ReasonReplaced means the entry's value was overwritten.
+	ReasonReplaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonRemoved:
+		return "removed"
+	case ReasonPurged:
+		return "purged"
+	case ReasonExpired:
+		return "expired"
+	case ReasonReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// EvictCallbackWithReason is used to get a callback when a cache entry is
+// evicted, along with the reason it was evicted.
+type EvictCallbackWithReason func(key interface{}, value interface{}, reason EvictReason)
+
+// EvictCallbackErr is used to get a callback when a cache entry is evicted,
+// for handlers that can fail. Errors are collected by the checked variants
+// of the operations that trigger evictions, such as AddChecked.
+type EvictCallbackErr func(key interface{}, value interface{}) error
+
+// EvictErrors holds the errors returned by an EvictCallbackErr during a
+// single operation, in the order the evictions happened.
+type EvictErrors []error
+
+func (e EvictErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// NewLRUWithEvictErr constructs a fixed size cache with an eviction callback
+// that can fail.
+func NewLRUWithEvictErr(size int, onEvict EvictCallbackErr) (*LRU, error) {
+	c, err := NewLRUWithAcquireAndEvict(size, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.onEvictErr = onEvict
+	return c, nil
+}
+
+// AddChecked adds a value to the cache like Add, additionally returning the
+// errors of any eviction callbacks it triggered as EvictErrors.
+func (c *LRUGeneric[K, V]) AddChecked(key K, value V) (evicted bool, err error) {
+	err = c.checked(func() {
+		evicted = c.Add(key, value)
+	})
+	return evicted, err
+}
+
+// RemoveChecked removes the provided key from the cache like Remove,
+// additionally returning the error of the eviction callback.
+func (c *LRUGeneric[K, V]) RemoveChecked(key K) (present bool, err error) {
+	err = c.checked(func() {
+		present = c.Remove(key)
+	})
+	return present, err
+}
+
+// PurgeChecked clears the cache like Purge, additionally returning the
+// errors of the eviction callbacks as EvictErrors. Every entry is removed
+// even if some callbacks fail.
+func (c *LRUGeneric[K, V]) PurgeChecked() error {
+	return c.checked(c.Purge)
+}
+
+// PanicHandler receives the value recovered from a panicking evict callback,
+// along with the entry it was called for.
+type PanicHandler func(key, value interface{}, recovered interface{})
+
+// WithPanicHandler makes the cache recover from panics in its evict
+// callbacks and report them to handler, instead of letting them propagate
+// to the caller. The entry is removed either way; the remaining callbacks for
+// that entry are skipped.
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(c *LRU) error {
+		c.onPanic = handler
+		return nil
+	}
+}
+
+// WithFireEvictOnReplace controls whether overwriting the value of an
+// existing key, through Add, UpdateValue or the like, fires the evict
+// callbacks for the old value with ReasonReplaced before the new value is
+// stored. It defaults to false. Re-adding a value equal to the current one
+// fires nothing, so that a value holding a resource is not released while
+// still cached.
+func WithFireEvictOnReplace(fire bool) Option {
+	return func(c *LRU) error {
+		c.evictOnReplace = fire
+		return nil
+	}
+}
+
+// replaced fires the evict callbacks for the value of kv, about to be
+// overwritten by value, unless the two are equal.
+func (c *LRUGeneric[K, V]) replaced(kv *entry[K, V], value V) {
+	if sameValue(kv.value, value) {
+		return
+	}
+	old := *kv
+	if kv.dirty() {
+		// The entry stays in the cache, so a dirty value is not written back
+		ext := *kv.ext
+		ext.dirty = false
+		old.ext = &ext
+	}
+	c.evicted(&old, ReasonReplaced)
+}
+
+// sameValue reports whether a and b are equal, treating values that cannot
+// be compared, such as slices, as different.
+func sameValue(a, b interface{}) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// checked runs f, collecting the errors of the eviction callbacks it
+// triggers.
+func (c *LRUGeneric[K, V]) checked(f func()) error {
+	var errs EvictErrors
+	c.evictErrs = &errs
+	defer func() {
+		c.evictErrs = nil
+	}()
+	f()
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// evicted fires the eviction callbacks for an entry that has been removed.
+func (c *LRUGeneric[K, V]) evicted(kv *entry[K, V], reason EvictReason) {
+	if len(c.refs) > 0 && c.refs[kv.key] > 0 {
+		c.deferred[kv.key] = append(c.deferred[kv.key], deferredEviction[K, V]{kv, reason})
+		return
+	}
+	key, value := kv.key, kv.value
+	if c.onPanic != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				c.onPanic(key, value, r)
+			}
+		}()
+	}
+	if reason == ReasonRemoved {
+		c.emit(EventRemove, key, value)
+	} else {
+		c.emit(EventEvict, key, value)
+	}
+	// Write back first, so a failing callback cannot lose the modification
+	if kv.dirty() && c.onWriteBack != nil {
+		c.onWriteBack(key, value)
+	}
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+	if c.onEvictReason != nil {
+		c.onEvictReason(key, value, reason)
+	}
+	if c.onEvictMeta != nil {
+		c.onEvictMeta(key, value, kv.meta())
+	}
+	if c.onEvictErr != nil {
+		if err := c.onEvictErr(key, value); err != nil && c.evictErrs != nil {
+			*c.evictErrs = append(*c.evictErrs, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package simplelru
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test that failing eviction callbacks are surfaced by checked operations
+func TestLRU_EvictErr(t *testing.T) {
+	errEvict := errors.New("flush failed")
+	evictCounter := 0
+	l, err := NewLRUWithEvictErr(4, func(k, v interface{}) error {
+		evictCounter++
+		if k.(int)%2 == 0 {
+			return errEvict
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := l.AddChecked(i, i); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	evicted, err := l.AddChecked(4, 4) // evicts 0
+	if !evicted || err == nil {
+		t.Fatalf("eviction error should be returned: %v, %v", evicted, err)
+	}
+	if errs, ok := err.(EvictErrors); !ok || len(errs) != 1 || errs[0] != errEvict {
+		t.Fatalf("bad errors: %v", err)
+	}
+
+	if _, err := l.AddChecked(5, 5); err != nil {
+		t.Fatalf("errors should not carry over between operations: %v", err)
+	}
+	if present, err := l.RemoveChecked(3); !present || err != nil {
+		t.Fatalf("bad: %v, %v", present, err)
+	}
+
+	// Unchecked operations drop errors
+	l.Remove(2)
+
+	// Entries left: 4, 5. The failing one doesn't stop the purge.
+	l.Add(6, 6)
+	err = l.PurgeChecked()
+	if errs, ok := err.(EvictErrors); !ok || len(errs) != 2 {
+		t.Fatalf("bad errors: %v", err)
+	}
+	if l.Len() != 0 {
+		t.Fatalf("purge should complete despite errors: %v", l.Len())
+	}
+	if err.Error() != "flush failed; flush failed" {
+		t.Errorf("bad message: %v", err)
+	}
+	if evictCounter != 7 {
+		t.Errorf("bad evict count: %v", evictCounter)
+	}
+}
+
+// Test that the reason callback reports why each entry was evicted
+func TestLRU_EvictReason(t *testing.T) {
+	reasons := make(map[interface{}]EvictReason)
+	evictCounter := 0
+	l, err := NewLRUWithOptions(2,
+		WithEvictReasonCallback(func(k, v interface{}, reason EvictReason) {
+			reasons[k] = reason
+		}),
+		WithEvictCallback(func(k, v interface{}) {
+			evictCounter++
+		}),
+	)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1
+	l.Remove(2)
+	l.AddWithTTL(4, 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	l.Get(4)
+	l.Add(5, 5)
+	l.Purge()
+
+	want := map[interface{}]EvictReason{
+		1: ReasonCapacity,
+		2: ReasonRemoved,
+		3: ReasonPurged,
+		4: ReasonExpired,
+		5: ReasonPurged,
+	}
+	if len(reasons) != len(want) {
+		t.Fatalf("bad reasons: %v", reasons)
+	}
+	for k, r := range want {
+		if reasons[k] != r {
+			t.Errorf("bad reason for %v: %v != %v", k, reasons[k], r)
+		}
+	}
+	if evictCounter != 5 {
+		t.Errorf("plain callback should still fire: %v", evictCounter)
+	}
+	if ReasonExpired.String() != "expired" {
+		t.Errorf("bad string: %v", ReasonExpired)
+	}
+}
+
+// Test that a panicking onEvict leaves the cache consistent
+func TestLRU_PanicHandler(t *testing.T) {
+	onEvict := func(k, v interface{}) {
+		if k.(int)%2 == 0 {
+			panic("already closed")
+		}
+	}
+	var recovered []interface{}
+	l, err := NewLRUWithOptions(2,
+		WithEvictCallback(onEvict),
+		WithPanicHandler(func(k, v, r interface{}) {
+			recovered = append(recovered, k)
+		}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(0, 0)
+	l.Add(1, 1)
+	if !l.Add(2, 2) { // evicts 0, which panics
+		t.Fatalf("an eviction should have occurred")
+	}
+	if len(recovered) != 1 || recovered[0] != 0 {
+		t.Fatalf("bad recovered: %v", recovered)
+	}
+	if l.Len() != 2 || len(l.Keys()) != 2 || l.Contains(0) {
+		t.Fatalf("cache should be consistent: %v", l.Keys())
+	}
+	l.Add(3, 3)
+	l.Purge()
+	if l.Len() != 0 || len(recovered) != 2 {
+		t.Fatalf("bad: %v, %v", l.Len(), recovered)
+	}
+
+	// Without a handler the panic propagates, but Purge still completes
+	plain, err := NewLRUWithEvict(4, onEvict)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		plain.Add(i, i)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the panic should propagate")
+			}
+		}()
+		plain.Purge()
+	}()
+	if plain.Len() != 0 || len(plain.Keys()) != 0 {
+		t.Errorf("cache should be empty: %v", plain.Keys())
+	}
+	plain.Add(5, 5)
+	if !plain.Contains(5) {
+		t.Errorf("cache should be usable after the panic")
+	}
+}
+
+// Test that WithFireEvictOnReplace releases overwritten values
+func TestLRU_FireEvictOnReplace(t *testing.T) {
+	for _, fire := range []bool{false, true} {
+		var released []interface{}
+		var reasons []EvictReason
+		l, err := NewLRUWithOptions(2,
+			WithFireEvictOnReplace(fire),
+			WithEvictCallback(func(k, v interface{}) { released = append(released, v) }),
+			WithEvictReasonCallback(func(k, v interface{}, r EvictReason) { reasons = append(reasons, r) }))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		l.Add(1, "a")
+		l.Add(1, "b")
+		l.UpdateValue(1, "c")
+		l.Add(1, "c") // the same value is not released
+		l.Add(1, []int{1})
+		l.Add(1, []int{1})
+
+		if !fire {
+			if len(released) != 0 {
+				t.Fatalf("overwrites should not fire onEvict: %v", released)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(released, []interface{}{"a", "b", "c", []int{1}}) {
+			t.Fatalf("bad released values: %v", released)
+		}
+		for _, r := range reasons {
+			if r != ReasonReplaced {
+				t.Fatalf("bad reason: %v", r)
+			}
+		}
+		if v, _ := l.Peek(1); !reflect.DeepEqual(v, []int{1}) || l.Stats().Evictions != 0 {
+			t.Fatalf("bad: %v, %+v", v, l.Stats())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package simplelru
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoFetcher is returned by GetOrFetch on a miss in a cache constructed
+// without WithFetcher.
+var ErrNoFetcher = errors.New("simplelru: no fetcher configured")
+
+// Fetcher is a read-through source for the values of a cache, used by
+// GetOrFetch on a miss.
+type Fetcher interface {
+	// Fetch returns the value for key. It should return promptly once ctx
+	// is done.
+	Fetch(ctx context.Context, key interface{}) (interface{}, error)
+}
+
+// WithFetcher sets the fetcher that GetOrFetch calls on a miss.
+func WithFetcher(fetcher Fetcher) Option {
+	return func(c *LRU) error {
+		c.fetcher = fetcher
+		return nil
+	}
+}
+
+// WithNegativeFetchTTL makes GetOrFetch cache a failed fetch as a negative
+// entry for ttl, as AddNegative does, so that the fetcher is not called
+// again for the key until the entry expires. Cancellation of the caller's
+// context is never cached.
+func WithNegativeFetchTTL(ttl time.Duration) Option {
+	return func(c *LRU) error {
+		if ttl <= 0 {
+			return errors.New("Must provide a positive negative fetch TTL")
+		}
+		c.negativeFetchTTL = ttl
+		return nil
+	}
+}
+
+// GetOrFetch looks up a key's value from the cache, and on a miss calls the
+// fetcher set by WithFetcher, caching and returning the value it fetched.
+// Like GetOrLoadContext, it returns ctx.Err() if ctx is done before or
+// during the fetch, and ErrNegativeEntry for a negative entry. A fetch error
+// is returned without caching anything, unless WithNegativeFetchTTL is set.
+func (c *LRUGeneric[K, V]) GetOrFetch(ctx context.Context, key K) (value V, err error) {
+	key = c.normalize(key)
+	if v, ok, negative := c.Lookup(key); ok {
+		if negative {
+			return value, ErrNegativeEntry
+		}
+		return v, nil
+	}
+	if err = ctx.Err(); err != nil {
+		return value, err
+	}
+	v, err := c.Fetch(ctx, key)
+	if err = c.AddFetched(ctx, key, v, err); err != nil {
+		return value, err
+	}
+	return v, nil
+}
+
+// Fetch calls the fetcher set by WithFetcher for key, without looking up or
+// updating the cache, and returns ErrNoFetcher if there is none. It only
+// reads the fetcher, so a locking wrapper can call it unlocked and then
+// store the outcome with AddFetched.
+func (c *LRUGeneric[K, V]) Fetch(ctx context.Context, key K) (value V, err error) {
+	if c.fetcher == nil {
+		return value, ErrNoFetcher
+	}
+	return c.fetcher.Fetch(ctx, c.normalize(key))
+}
+
+// AddFetched stores the outcome of a Fetch made with ctx the way GetOrFetch
+// does: value is added if err is nil and ctx is not done, and a failed
+// fetch is cached as a negative entry if WithNegativeFetchTTL is set. It
+// returns err, or ctx.Err() if the fetch succeeded after ctx was done.
+func (c *LRUGeneric[K, V]) AddFetched(ctx context.Context, key K, value V, err error) error {
+	if err != nil {
+		if c.negativeFetchTTL > 0 && ctx.Err() == nil && err != ErrNoFetcher {
+			c.AddNegative(key, c.negativeFetchTTL)
+		}
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	c.Add(key, value)
+	return nil
+}
@@ -0,0 +1,116 @@
+package simplelru
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fetcherFunc adapts a function to the Fetcher interface
+type fetcherFunc func(ctx context.Context, key interface{}) (interface{}, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, key interface{}) (interface{}, error) {
+	return f(ctx, key)
+}
+
+// Test that GetOrFetch caches fetched values but not errors
+func TestLRU_GetOrFetch(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	fetches := 0
+	l, err := NewLRUWithOptions(4, WithFetcher(fetcherFunc(
+		func(ctx context.Context, key interface{}) (interface{}, error) {
+			fetches++
+			if key == "bad" {
+				return nil, errFetch
+			}
+			return key.(string) + "!", nil
+		})))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if v, err := l.GetOrFetch(ctx, "good"); err != nil || v != "good!" {
+			t.Fatalf("bad: %v, %v", v, err)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("fetched value should be cached: %v fetches", fetches)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.GetOrFetch(ctx, "bad"); err != errFetch {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if fetches != 3 || l.Contains("bad") {
+		t.Fatalf("errors should not be cached: %v fetches", fetches)
+	}
+
+	noFetcher, err := NewLRUWithOptions(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := noFetcher.GetOrFetch(ctx, "good"); err != ErrNoFetcher {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// Test that GetOrFetch returns once a blocked fetch's context is cancelled
+func TestLRU_GetOrFetchCancel(t *testing.T) {
+	l, err := NewLRUWithOptions(4,
+		WithNegativeFetchTTL(time.Hour),
+		WithFetcher(fetcherFunc(func(ctx context.Context, key interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.GetOrFetch(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("err: %v", err)
+	}
+	if l.Contains(1) {
+		t.Fatalf("cancellation should not be cached")
+	}
+	if _, err := l.GetOrFetch(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("a done context should fail without fetching: %v", err)
+	}
+}
+
+// Test that WithNegativeFetchTTL caches fetch errors as negative entries
+func TestLRU_GetOrFetchNegative(t *testing.T) {
+	now := time.Now()
+	fetches := 0
+	l, err := NewLRUWithOptions(4,
+		WithClock(func() time.Time { return now }),
+		WithNegativeFetchTTL(time.Minute),
+		WithFetcher(fetcherFunc(func(ctx context.Context, key interface{}) (interface{}, error) {
+			fetches++
+			return nil, errors.New("not found")
+		})))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := l.GetOrFetch(ctx, 1); err == nil || err == ErrNegativeEntry {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.GetOrFetch(ctx, 1); err != ErrNegativeEntry || fetches != 1 {
+		t.Fatalf("error should be cached as negative: %v, %v fetches", err, fetches)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := l.GetOrFetch(ctx, 1); err == ErrNegativeEntry || fetches != 2 {
+		t.Fatalf("negative entry should have expired: %v, %v fetches", err, fetches)
+	}
+	if _, err := NewLRUWithOptions(4, WithNegativeFetchTTL(0)); err == nil {
+		t.Fatalf("should reject a non-positive TTL")
+	}
+}
@@ -0,0 +1,34 @@
+package simplelru
+
+import (
+	"container/list"
+	"errors"
+)
+
+// NewLRUGenericWithAcquireAndEvict constructs a fixed size typed cache with
+// the given acquire and eviction callbacks.
+func NewLRUGenericWithAcquireAndEvict[K comparable, V any](
+	size int,
+	onAcquire func(key K, value V),
+	onEvict func(key K, value V),
+) (*LRUGeneric[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("Must provide a positive size")
+	}
+	c := &LRUGeneric[K, V]{
+		size:      size,
+		items:     make(map[K]*list.Element),
+		onEvict:   onEvict,
+		onAcquire: onAcquire,
+	}
+	return c, nil
+}
+
+// NewLRUGenericWithEvict constructs a fixed size typed cache with the given
+// eviction callback.
+func NewLRUGenericWithEvict[K comparable, V any](
+	size int,
+	onEvict func(key K, value V),
+) (*LRUGeneric[K, V], error) {
+	return NewLRUGenericWithAcquireAndEvict[K, V](size, nil, onEvict)
+}
@@ -0,0 +1,168 @@
+package simplelru
+
+import (
+	"strconv"
+	"testing"
+)
+
+func BenchmarkLRU_Int(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Add(i%16384, i)
+		l.Get(i % 16384)
+	}
+}
+
+func BenchmarkLRUGeneric_Int(b *testing.B) {
+	l, err := NewLRUGenericWithEvict[int, int](8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Add(i%16384, i)
+		l.Get(i % 16384)
+	}
+}
+
+func BenchmarkLRU_String(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys := make([]string, 16384)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := keys[i%len(keys)]
+		l.Add(k, k)
+		l.Get(k)
+	}
+}
+
+func BenchmarkLRUGeneric_String(b *testing.B) {
+	l, err := NewLRUGenericWithEvict[string, string](8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys := make([]string, 16384)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := keys[i%len(keys)]
+		l.Add(k, k)
+		l.Get(k)
+	}
+}
+
+func TestLRUGeneric(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k int, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	}
+	l, err := NewLRUGenericWithEvict(128, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	if evictCounter != 128 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+
+	for i, k := range l.Keys() {
+		if v, ok := l.Get(k); !ok || v != k || v != i+128 {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	for i := 0; i < 128; i++ {
+		if _, ok := l.Get(i); ok {
+			t.Fatalf("should be evicted")
+		}
+	}
+	for i := 128; i < 192; i++ {
+		if !l.Remove(i) {
+			t.Fatalf("should be contained")
+		}
+		if l.Remove(i) {
+			t.Fatalf("should not be contained")
+		}
+	}
+
+	l.Get(192) // expect 192 to be last key in l.Keys()
+
+	for i, k := range l.Keys() {
+		if (i < 63 && k != i+193) || (i == 63 && k != 192) {
+			t.Fatalf("out of order key: %v", k)
+		}
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if v, ok := l.Get(200); ok || v != 0 {
+		t.Fatalf("should contain nothing")
+	}
+}
+
+func TestLRUGeneric_GetOrAdd(t *testing.T) {
+	acquired := 0
+	l, err := NewLRUGenericWithAcquireAndEvict[string, int](
+		1,
+		func(k string, v int) { acquired++ },
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if v, evicted, added := l.GetOrAdd("a", 1); v != 1 || evicted || !added {
+		t.Errorf("bad: %v, %v, %v", v, evicted, added)
+	}
+	if v, evicted, added := l.GetOrAdd("a", 2); v != 1 || evicted || added {
+		t.Errorf("bad: %v, %v, %v", v, evicted, added)
+	}
+	if v, evicted, added := l.GetOrAdd("b", 3); v != 3 || !evicted || !added {
+		t.Errorf("bad: %v, %v, %v", v, evicted, added)
+	}
+	if acquired != 3 {
+		t.Errorf("bad acquire count: %v", acquired)
+	}
+
+	k, v, ok := l.GetOldest()
+	if !ok || k != "b" || v != 3 {
+		t.Errorf("bad oldest: %v, %v, %v", k, v, ok)
+	}
+	if _, _, ok := l.RemoveOldest(); !ok {
+		t.Errorf("missing")
+	}
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Errorf("should be empty")
+	}
+}
@@ -0,0 +1,89 @@
+package simplelru
+
+import "errors"
+
+// WithGhostList makes the cache remember the keys of its last size
+// capacity evictions, as reported by RecentlyEvicted, and count how often a
+// remembered key is added back, as reported by ThrashRate.
+func WithGhostList(size int) Option {
+	return func(c *LRU) error {
+		if size <= 0 {
+			return errors.New("Must provide a positive ghost list size")
+		}
+		c.ghosts = &ghostRing[interface{}]{
+			keys:   make([]interface{}, 0, size),
+			counts: make(map[interface{}]int, size),
+		}
+		return nil
+	}
+}
+
+// RecentlyEvicted returns the keys most recently evicted to make room,
+// from oldest to newest, or nil if the cache has no ghost list.
+func (c *LRUGeneric[K, V]) RecentlyEvicted() []K {
+	if c.ghosts == nil {
+		return nil
+	}
+	return c.ghosts.list()
+}
+
+// ThrashRate returns the fraction of new entries whose key was among the
+// recently evicted ones, or 0 if the cache has no ghost list or has added
+// nothing. A high rate means the cache keeps evicting keys it still needs,
+// a sign that it is too small.
+func (c *LRUGeneric[K, V]) ThrashRate() float64 {
+	if c.ghosts == nil || c.ghosts.inserts == 0 {
+		return 0
+	}
+	return float64(c.ghosts.hits) / float64(c.ghosts.inserts)
+}
+
+// ghostRing is a fixed size FIFO of evicted keys.
+type ghostRing[K comparable] struct {
+	keys    []K
+	next    int       // index of the oldest key once keys is full
+	counts  map[K]int // occurrences of each key in keys
+	inserts uint64
+	hits    uint64
+}
+
+// push remembers key, forgetting the oldest key if the ring is full.
+func (g *ghostRing[K]) push(key K) {
+	if len(g.keys) < cap(g.keys) {
+		g.keys = append(g.keys, key)
+	} else {
+		old := g.keys[g.next]
+		if g.counts[old]--; g.counts[old] == 0 {
+			delete(g.counts, old)
+		}
+		g.keys[g.next] = key
+		g.next = (g.next + 1) % len(g.keys)
+	}
+	g.counts[key]++
+}
+
+// observe records the insertion of a new entry for key.
+func (g *ghostRing[K]) observe(key K) {
+	g.inserts++
+	if g.counts[key] > 0 {
+		g.hits++
+	}
+}
+
+// list returns the remembered keys from oldest to newest.
+func (g *ghostRing[K]) list() []K {
+	keys := make([]K, 0, len(g.keys))
+	keys = append(keys, g.keys[g.next:]...)
+	return append(keys, g.keys[:g.next]...)
+}
+
+// clone returns an independent copy of the ring.
+func (g *ghostRing[K]) clone() *ghostRing[K] {
+	clone := *g
+	clone.keys = append(make([]K, 0, cap(g.keys)), g.keys...)
+	clone.counts = make(map[K]int, len(g.counts))
+	for k, n := range g.counts {
+		clone.counts[k] = n
+	}
+	return &clone
+}
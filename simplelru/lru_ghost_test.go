@@ -0,0 +1,60 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that the ghost list remembers recent evictions in order
+func TestLRU_RecentlyEvicted(t *testing.T) {
+	l, err := NewLRUWithOptions(2, WithGhostList(3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		l.Add(i, i)
+	}
+	l.Remove(6) // not a capacity eviction
+	if got := l.RecentlyEvicted(); !reflect.DeepEqual(got, []interface{}{2, 3, 4}) {
+		t.Fatalf("bad ghosts: %v", got)
+	}
+
+	clone := l.Clone()
+	l.Add(7, 7)
+	if got := clone.RecentlyEvicted(); !reflect.DeepEqual(got, []interface{}{2, 3, 4}) {
+		t.Fatalf("clone should not share the ghost list: %v", got)
+	}
+
+	plain, err := NewLRUWithOptions(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain.Add(1, 1)
+	if plain.RecentlyEvicted() != nil || plain.ThrashRate() != 0 {
+		t.Fatalf("a cache without a ghost list should report nothing")
+	}
+}
+
+// Test that cycling through more keys than the cache holds thrashes
+func TestLRU_ThrashRate(t *testing.T) {
+	l, err := NewLRUWithOptions(4, WithGhostList(8))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A working set that fits does not thrash
+	for i := 0; i < 40; i++ {
+		l.Add(i%4, i)
+	}
+	if r := l.ThrashRate(); r != 0 {
+		t.Fatalf("bad thrash rate: %v", r)
+	}
+
+	// One just too large evicts each key before it comes back
+	for i := 0; i < 50; i++ {
+		l.Add(i%5, i)
+	}
+	if r := l.ThrashRate(); r < 0.5 {
+		t.Fatalf("thrash rate should be high: %v", r)
+	}
+}
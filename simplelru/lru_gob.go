@@ -0,0 +1,60 @@
+package simplelru
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// WriteTo encodes the live entries of the cache, as returned by Snapshot, to
+// w with encoding/gob, ordered from newest to oldest along with their
+// remaining TTL, without updating the recent-ness of any key. The
+// concrete types of keys and values other than the basic types must be
+// registered with gob.Register. It returns the number of bytes written.
+func (c *LRUGeneric[K, V]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(c.Snapshot())
+	return cw.n, err
+}
+
+// ReadFrom purges the cache and refills it from entries encoded by WriteTo,
+// preserving their recency order. As with UnmarshalJSON, the cache must
+// already be constructed. It returns the number of bytes read, which may
+// include data buffered past the end of the encoding.
+func (c *LRUGeneric[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	var entries []EntryGeneric[K, V]
+	if err := gob.NewDecoder(cr).Decode(&entries); err != nil {
+		return cr.n, err
+	}
+	for _, e := range entries {
+		if k := any(e.Key); k != nil && !reflect.TypeOf(k).Comparable() {
+			return cr.n, fmt.Errorf("simplelru: cannot use %T as a cache key", k)
+		}
+	}
+	c.Restore(entries)
+	return cr.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
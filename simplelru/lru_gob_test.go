@@ -0,0 +1,56 @@
+package simplelru
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+type gobPoint struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(gobPoint{})
+}
+
+// Test that a cache of struct values survives a gob round trip in order
+func TestLRU_Gob(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", gobPoint{1, 2})
+	l.Add("b", gobPoint{3, 4})
+	l.Add("c", gobPoint{5, 6})
+	l.Get("a")
+
+	var buf bytes.Buffer
+	n, err := l.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("bad byte count: %v != %v", n, buf.Len())
+	}
+
+	restored, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restored.Add("stale", gobPoint{})
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), l.Snapshot()) {
+		t.Errorf("bad entries: %v", restored.Snapshot())
+	}
+	if restored.Contains("stale") {
+		t.Errorf("ReadFrom should replace the existing entries")
+	}
+
+	if _, err := restored.ReadFrom(bytes.NewReader([]byte("junk"))); err == nil {
+		t.Errorf("invalid input should fail")
+	}
+}
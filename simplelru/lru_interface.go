@@ -1,41 +1,114 @@
 package simplelru
 
-// LRUCache is the interface for simple LRU cache.
-type LRUCache interface {
+// LRUCacheGeneric is the interface for simple LRU cache with typed keys and
+// values.
+type LRUCacheGeneric[K comparable, V any] interface {
 	// Adds a value to the cache, returns true if an eviction occurred and
 	// updates the "recently used"-ness of the key.
-	Add(key, value interface{}) bool
+	Add(key K, value V) bool
 
 	// Returns key's value from the cache and
 	// updates the "recently used"-ness of the key. #value, isFound
-	Get(key interface{}) (value interface{}, ok bool)
+	Get(key K) (value V, ok bool)
 
 	// GetOrAdd tries to lookup a key in the cache, returning the value.
 	// Otherwise, add the key value pair, returning the value.
 	// Along with if an eviction occurred and if value was added.
-	GetOrAdd(key, value interface{}) (val interface{}, evicted bool, added bool)
+	GetOrAdd(key K, value V) (val V, evicted bool, added bool)
 
 	// Check if a key exsists in cache without updating the recent-ness.
-	Contains(key interface{}) (ok bool)
+	Contains(key K) (ok bool)
+
+	// Checks if a key is in the cache without updating the recent-ness,
+	// and if not, adds the value. #isFound, evicted
+	ContainsOrAdd(key K, value V) (ok, evicted bool)
+
+	// Returns key's value if found without updating the recent-ness,
+	// otherwise adds the value. #previous, isFound, evicted
+	PeekOrAdd(key K, value V) (previous V, ok, evicted bool)
 
 	// Returns key's value without updating the "recently used"-ness of the key.
-	Peek(key interface{}) (value interface{}, ok bool)
+	Peek(key K) (value V, ok bool)
 
 	// Removes a key from the cache.
-	Remove(key interface{}) bool
+	Remove(key K) bool
 
 	// Removes the oldest entry from cache.
-	RemoveOldest() (interface{}, interface{}, bool)
+	RemoveOldest() (K, V, bool)
 
 	// Returns the oldest entry from the cache. #key, value, isFound
-	GetOldest() (interface{}, interface{}, bool)
+	GetOldest() (K, V, bool)
 
 	// Returns a slice of the keys in the cache, from oldest to newest.
-	Keys() []interface{}
+	Keys() []K
+
+	// Returns a slice of the values in the cache, from oldest to newest.
+	Values() []V
 
 	// Returns the number of items in the cache.
 	Len() int
 
+	// Returns the maximum number of items in the cache.
+	Cap() int
+
+	// Resizes cache, returning number evicted
+	Resize(int) int
+
 	// Clear all cache entries
 	Purge()
 }
+
+// LRUCache is the interface for simple LRU cache.
+type LRUCache = LRUCacheGeneric[interface{}, interface{}]
+
+// ReadOnlyGeneric is the read side of a cache with typed keys and values,
+// for code that must not add, update or remove entries.
+type ReadOnlyGeneric[K comparable, V any] interface {
+	// Returns key's value from the cache and
+	// updates the "recently used"-ness of the key. #value, isFound
+	Get(key K) (value V, ok bool)
+
+	// Returns key's value without updating the "recently used"-ness of the key.
+	Peek(key K) (value V, ok bool)
+
+	// Check if a key exists in cache without updating the recent-ness.
+	Contains(key K) (ok bool)
+
+	// Returns the number of items in the cache.
+	Len() int
+
+	// Returns a slice of the keys in the cache, from oldest to newest.
+	Keys() []K
+
+	// Returns a slice of the values in the cache, from oldest to newest.
+	Values() []V
+}
+
+// ReadOnly is the read side of a cache, for code that must not add, update
+// or remove entries.
+type ReadOnly = ReadOnlyGeneric[interface{}, interface{}]
+
+// readOnlyView hides every method of an LRUGeneric but those of
+// ReadOnlyGeneric, so that a view cannot be asserted back to the cache or
+// to LRUCacheGeneric.
+type readOnlyView[K comparable, V any] struct {
+	c *LRUGeneric[K, V]
+}
+
+// ReadOnlyView returns a view of the cache that only offers the methods of
+// ReadOnlyGeneric, which for an LRU is ReadOnly. The view's Get still promotes the key, as LRU.Get does, so that
+// reads through the view count towards recency and the stats; it also
+// removes an expired entry it finds. Use Peek to read without affecting the
+// cache. Like the cache, the view is not safe for concurrent use.
+func (c *LRUGeneric[K, V]) ReadOnlyView() ReadOnlyGeneric[K, V] {
+	return readOnlyView[K, V]{c: c}
+}
+
+func (v readOnlyView[K, V]) Get(key K) (V, bool)  { return v.c.Get(key) }
+func (v readOnlyView[K, V]) Peek(key K) (V, bool) { return v.c.Peek(key) }
+func (v readOnlyView[K, V]) Contains(key K) bool  { return v.c.Contains(key) }
+func (v readOnlyView[K, V]) Len() int             { return v.c.Len() }
+func (v readOnlyView[K, V]) Keys() []K            { return v.c.Keys() }
+func (v readOnlyView[K, V]) Values() []V          { return v.c.Values() }
+
+var _ LRUCache = (*LRU)(nil)
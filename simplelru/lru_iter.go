@@ -0,0 +1,19 @@
+//go:build go1.23
+
+package simplelru
+
+import "iter"
+
+// All returns an iterator over the entries of the cache, from oldest to
+// newest, for use with range. Like Range, it does not update the recent-ness
+// of any key, and the loop body may Remove the current key but must not
+// otherwise modify the cache.
+func (c *LRUGeneric[K, V]) All() iter.Seq2[K, V] {
+	return c.Range
+}
+
+// Backward returns an iterator over the entries of the cache, from newest to
+// oldest. It is otherwise like All.
+func (c *LRUGeneric[K, V]) Backward() iter.Seq2[K, V] {
+	return c.RangeNewest
+}
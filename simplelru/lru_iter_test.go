@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that All and Backward can be ranged over without promoting entries
+func TestLRU_All(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Add(i, i*10)
+	}
+
+	var keys []interface{}
+	for k, v := range l.All() {
+		if v != k.(int)*10 {
+			t.Fatalf("bad value for %v: %v", k, v)
+		}
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{0, 1, 2}) {
+		t.Errorf("bad order: %v", keys)
+	}
+
+	keys = nil
+	for k := range l.Backward() {
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{2, 1, 0}) {
+		t.Errorf("bad backward order: %v", keys)
+	}
+
+	keys = nil
+	for k := range l.All() {
+		keys = append(keys, k)
+		break
+	}
+	if !reflect.DeepEqual(keys, []interface{}{0}) {
+		t.Errorf("break should stop the iteration: %v", keys)
+	}
+
+	// Iterating did not promote 0, so it is still the next to go
+	l.Add(3, 30)
+	if l.Contains(0) {
+		t.Errorf("iteration should not have updated recent-ness of 0")
+	}
+}
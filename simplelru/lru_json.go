@@ -0,0 +1,77 @@
+package simplelru
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// JSONDecodeHook decodes the raw JSON of an entry's key and value during
+// UnmarshalJSON. It lets callers restore concrete types that a plain decode
+// into interface{} would lose.
+type JSONDecodeHook func(key, value json.RawMessage) (interface{}, interface{}, error)
+
+// jsonEntry is the undecoded form of an Entry.
+type jsonEntry struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+	TTL   time.Duration   `json:"ttl,omitempty"`
+}
+
+// SetJSONDecodeHook sets the hook used by UnmarshalJSON to decode entries.
+// A nil hook restores the default decoding into the key and value types.
+func (c *LRUGeneric[K, V]) SetJSONDecodeHook(hook func(key, value json.RawMessage) (K, V, error)) {
+	c.decodeHook = hook
+}
+
+// MarshalJSON encodes the live entries of the cache as an array of
+// {"key": ..., "value": ...} objects ordered from newest to oldest, with a
+// "ttl" in nanoseconds for entries that expire. It does not update the
+// recent-ness of any key.
+func (c *LRUGeneric[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+// UnmarshalJSON purges the cache and refills it from an array produced by
+// MarshalJSON, rebuilding recency order from array position. The cache must
+// already be constructed, since its capacity is not part of the encoding.
+//
+// Without a decode hook, keys and values decode as encoding/json decodes into
+// the key and value types. For an LRU that is interface{}: numbers become
+// float64, objects become map[string]interface{} and arrays become
+// []interface{}. Keys that decode to a type which cannot be used as a map
+// key, such as an object, are rejected with an error.
+func (c *LRUGeneric[K, V]) UnmarshalJSON(data []byte) error {
+	var raw []jsonEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	entries := make([]EntryGeneric[K, V], len(raw))
+	for i, r := range raw {
+		var err error
+		if c.decodeHook != nil {
+			entries[i].Key, entries[i].Value, err = c.decodeHook(r.Key, r.Value)
+		} else {
+			err = decodeJSONEntry(r, &entries[i])
+		}
+		if err != nil {
+			return err
+		}
+		entries[i].TTL = r.TTL
+		if k := any(entries[i].Key); k != nil && !reflect.TypeOf(k).Comparable() {
+			return fmt.Errorf("simplelru: cannot use %T as a cache key", k)
+		}
+	}
+	c.Restore(entries)
+	return nil
+}
+
+// decodeJSONEntry decodes r into e using the default decoding.
+func decodeJSONEntry[K comparable, V any](r jsonEntry, e *EntryGeneric[K, V]) error {
+	if err := json.Unmarshal(r.Key, &e.Key); err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Value, &e.Value)
+}
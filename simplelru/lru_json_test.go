@@ -0,0 +1,93 @@
+package simplelru
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Test that a cache of string keys and map values survives a JSON round trip
+func TestLRU_JSON(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", map[string]interface{}{"n": 1.0})
+	l.Add("b", map[string]interface{}{"n": 2.0})
+	l.Add("c", map[string]interface{}{"n": 3.0})
+	l.Get("a")
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := `[{"key":"a","value":{"n":1}},{"key":"c","value":{"n":3}},{"key":"b","value":{"n":2}}]`
+	if string(data) != want {
+		t.Fatalf("bad encoding: %s", data)
+	}
+
+	r, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(r.Keys(), l.Keys()) {
+		t.Errorf("bad order: %v != %v", r.Keys(), l.Keys())
+	}
+	if !reflect.DeepEqual(r.Values(), l.Values()) {
+		t.Errorf("bad values: %v != %v", r.Values(), l.Values())
+	}
+}
+
+// Test that a decode hook can restore concrete types
+func TestLRU_JSONDecodeHook(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, point{1, 2})
+	l.Add(2, point{3, 4})
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.SetJSONDecodeHook(func(key, value json.RawMessage) (interface{}, interface{}, error) {
+		var k int
+		var v point
+		if err := json.Unmarshal(key, &k); err != nil {
+			return nil, nil, err
+		}
+		err := json.Unmarshal(value, &v)
+		return k, v, err
+	})
+	if err := json.Unmarshal(data, r); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ok := r.Get(1); !ok || v != (point{1, 2}) {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+
+	// Without the hook numbers decode to float64
+	r.SetJSONDecodeHook(nil)
+	if err := json.Unmarshal(data, r); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !r.Contains(1.0) || r.Contains(1) {
+		t.Errorf("keys should decode to float64: %v", r.Keys())
+	}
+
+	if err := json.Unmarshal([]byte(`[{"key":{"a":1},"value":1}]`), r); err == nil {
+		t.Errorf("unhashable keys should be rejected")
+	}
+}
@@ -0,0 +1,47 @@
+package simplelru
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// compositeWidth is the number of parts held inline by a composite key;
+// longer keys nest the remaining parts in its tail.
+const compositeWidth = 4
+
+// compositeKey is the comparable form of a CompositeKey. Recording the
+// number of parts keeps keys of different lengths distinct even when one
+// of their parts is itself a composite key.
+type compositeKey struct {
+	n    int
+	head [compositeWidth]interface{}
+	tail interface{} // compositeKey of the parts after head, or nil
+}
+
+// CompositeKey builds a key from several parts, such as a (tenant, id)
+// pair, without formatting them into a string. Keys built from equal parts
+// in the same order are equal, and keys with different numbers of parts are
+// distinct. Each part must be comparable, that is usable as a map key; a
+// part that is not, such as a slice, makes CompositeKey panic.
+//
+// Any comparable struct, such as struct{ tenant, id string }, also works as
+// a key directly, and is both cheaper and type safe when the shape of the key
+// is known up front. CompositeKey suits keys whose parts vary at runtime.
+func CompositeKey(parts ...interface{}) interface{} {
+	for i, p := range parts {
+		if p != nil && !reflect.TypeOf(p).Comparable() {
+			panic(fmt.Sprintf("simplelru: CompositeKey part %d of type %T is not comparable", i, p))
+		}
+	}
+	return composite(parts)
+}
+
+// composite packs parts into a compositeKey, which is comparable.
+func composite(parts []interface{}) compositeKey {
+	k := compositeKey{n: len(parts)}
+	copy(k.head[:], parts)
+	if len(parts) > compositeWidth {
+		k.tail = composite(parts[compositeWidth:])
+	}
+	return k
+}
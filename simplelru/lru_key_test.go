@@ -0,0 +1,119 @@
+package simplelru
+
+import (
+	"strconv"
+	"testing"
+)
+
+// tenantKey is a struct key with a few fields
+type tenantKey struct {
+	tenant string
+	id     int
+}
+
+// Test that comparable structs work as keys
+func TestLRU_StructKeys(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(tenantKey{"acme", 1}, "a1")
+	l.Add(tenantKey{"acme", 2}, "a2")
+	l.Add(tenantKey{"initech", 1}, "i1")
+	if v, ok := l.Get(tenantKey{"acme", 1}); !ok || v != "a1" {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if v, ok := l.Get(tenantKey{"initech", 1}); !ok || v != "i1" {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if l.Contains(tenantKey{"initech", 2}) {
+		t.Fatalf("key should not be contained")
+	}
+}
+
+// Test that composite keys compare by their parts
+func TestCompositeKey(t *testing.T) {
+	l, err := NewLRUWithEvict(16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	keys := [][]interface{}{
+		{},
+		{"acme"},
+		{"acme", 1},
+		{"acme", 1, "x"},
+		{"acme", 1, "x", 2.5},
+		{"acme", 1, "x", 2.5, true},
+		{"acme", 1, "x", 2.5, true, tenantKey{"t", 3}, nil},
+		{1, "acme"},
+		{"acme", int64(1)},
+		{"t", 1, 2, 3, 4},
+		{"t", 1, 2, CompositeKey(3, 4)},
+		{"t", 1, 2, [2]interface{}{3, 4}},
+		{"t", 1, 2, 3, CompositeKey(4)},
+		{"acme", nil},
+		{"acme", nil, nil, nil, nil},
+	}
+	for i, parts := range keys {
+		l.Add(CompositeKey(parts...), i)
+	}
+	if l.Len() != len(keys) {
+		t.Fatalf("composite keys should be distinct: %v", l.Keys())
+	}
+	for i, parts := range keys {
+		copied := append([]interface{}(nil), parts...)
+		if v, ok := l.Get(CompositeKey(copied...)); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", parts, v, ok)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("a slice part should panic")
+		}
+	}()
+	CompositeKey("acme", []int{1})
+}
+
+func BenchmarkKey_StringConcat(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := "tenant" + ":" + strconv.Itoa(i%16384)
+		l.Add(k, i)
+		l.Get(k)
+	}
+}
+
+func BenchmarkKey_Struct(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := tenantKey{"tenant", i % 16384}
+		l.Add(k, i)
+		l.Get(k)
+	}
+}
+
+func BenchmarkKey_Composite(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := CompositeKey("tenant", i%16384)
+		l.Add(k, i)
+		l.Get(k)
+	}
+}
@@ -0,0 +1,42 @@
+package simplelru
+
+// EvictCallbackWithMeta is used to get a callback when a cache entry is
+// evicted, along with the metadata set by AddWithMeta, which is nil for
+// entries without any.
+type EvictCallbackWithMeta func(key interface{}, value interface{}, meta map[string]interface{})
+
+// WithEvictMetaCallback sets a callback fired when an entry is evicted,
+// along with its metadata.
+func WithEvictMetaCallback(onEvict EvictCallbackWithMeta) Option {
+	return func(c *LRU) error {
+		c.onEvictMeta = onEvict
+		return nil
+	}
+}
+
+// AddWithMeta adds a value to the cache like Add, attaching meta to the
+// entry in place of any metadata it had. The metadata is kept when the
+// value is later overwritten by Add, and is passed to the evict callback
+// set by WithEvictMetaCallback. The cache does not copy meta.
+func (c *LRUGeneric[K, V]) AddWithMeta(key K, value V, meta map[string]interface{}) (evicted bool) {
+	key = c.normalize(key)
+	evicted = c.Add(key, value)
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*entry[K, V]).extra().meta = meta
+	}
+	return evicted
+}
+
+// GetMeta returns the metadata of a key without updating its recent-ness.
+// Returns false if the key is absent or expired; a present key without
+// metadata is reported as (nil, true).
+func (c *LRUGeneric[K, V]) GetMeta(key K) (meta map[string]interface{}, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	if ent, ok := c.items[key]; ok {
+		if kv := ent.Value.(*entry[K, V]); !kv.expired(&now) {
+			return kv.meta(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,48 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that metadata survives overwrites and reaches the evict callback
+func TestLRU_Meta(t *testing.T) {
+	evictedMeta := make(map[interface{}]map[string]interface{})
+	l, err := NewLRUWithOptions(2, WithEvictMetaCallback(
+		func(k, v interface{}, meta map[string]interface{}) {
+			evictedMeta[k] = meta
+		}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	meta := map[string]interface{}{"source": "db", "version": 3}
+	l.AddWithMeta(1, "one", meta)
+	l.Add(1, "uno")
+	if got, ok := l.GetMeta(1); !ok || !reflect.DeepEqual(got, meta) {
+		t.Fatalf("metadata should survive an overwrite: %v, %v", got, ok)
+	}
+	if v, _ := l.Peek(1); v != "uno" {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	l.Add(2, "two")
+	if got, ok := l.GetMeta(2); !ok || got != nil {
+		t.Fatalf("bad metadata: %v, %v", got, ok)
+	}
+	if _, ok := l.GetMeta(3); ok {
+		t.Fatalf("3 should not be contained")
+	}
+
+	// GetMeta does not promote, so 1 is still the oldest
+	l.Add(3, "three")
+	if got, ok := evictedMeta[1]; !ok || !reflect.DeepEqual(got, meta) {
+		t.Fatalf("metadata should be passed on eviction: %v", evictedMeta)
+	}
+
+	l.AddWithMeta(2, "dos", map[string]interface{}{"version": 4})
+	l.Remove(2)
+	if got := evictedMeta[2]; got["version"] != 4 {
+		t.Fatalf("AddWithMeta should replace the metadata: %v", got)
+	}
+}
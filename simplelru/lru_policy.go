@@ -0,0 +1,58 @@
+package simplelru
+
+// EvictionPolicy chooses which entry to evict when a cache needs room for a
+// new entry or is over capacity.
+type EvictionPolicy interface {
+	// Victim returns the key of the entry to evict from c, which holds at
+	// least one entry. It must not modify c. If the key is not in c, the
+	// oldest entry is evicted instead.
+	Victim(c *LRU) (key interface{})
+}
+
+// LRUPolicy evicts the least recently used entry. It is the default.
+type LRUPolicy struct{}
+
+// Victim implements EvictionPolicy.
+func (LRUPolicy) Victim(c *LRU) interface{} {
+	key, _, _ := c.GetOldest()
+	return key
+}
+
+// MRUPolicy evicts the most recently used entry, which suits cyclic access
+// patterns larger than the cache.
+type MRUPolicy struct{}
+
+// Victim implements EvictionPolicy.
+func (MRUPolicy) Victim(c *LRU) interface{} {
+	key, _, _ := c.PeekNewest()
+	return key
+}
+
+// WithEvictionPolicy sets the policy that chooses which entry to evict.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *LRU) error {
+		c.policy = policy
+		return nil
+	}
+}
+
+// EvictionOrder returns the keys of the cache in the exact order in which
+// capacity evictions would remove them, first victim first, as chosen by
+// the eviction policy and skipping pinned entries, which are never evicted
+// for capacity. It simulates the evictions on a clone of the cache, so it
+// costs at least O(n) and does not fire any callback; it is meant for
+// testing custom policies.
+func (c *LRUGeneric[K, V]) EvictionOrder() []K {
+	sim := c.Clone()
+	order := make([]K, 0, sim.Len())
+	for ent := sim.victim(); ent != nil; ent = sim.victim() {
+		kv := ent.Value.(*entry[K, V])
+		// Unlink directly, so no callback, tier or ghost list sees it
+		sim.evictList.Remove(ent)
+		delete(sim.items, kv.key)
+		sim.currentCost -= kv.cost
+		sim.currentBytes -= kv.bytes
+		order = append(order, kv.key)
+	}
+	return order
+}
@@ -0,0 +1,119 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// largestPolicy evicts the entry with the largest int value
+type largestPolicy struct{}
+
+func (largestPolicy) Victim(c *LRU) interface{} {
+	var victim interface{}
+	largest := -1
+	c.Range(func(k, v interface{}) bool {
+		if n := v.(int); n > largest {
+			victim, largest = k, n
+		}
+		return true
+	})
+	return victim
+}
+
+// Test that a custom policy picks the victim instead of the oldest entry
+func TestLRU_EvictionPolicy(t *testing.T) {
+	l, err := NewLRUWithOptions(3, WithEvictionPolicy(largestPolicy{}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("a", 1)
+	l.Add("b", 9)
+	l.Add("c", 5)
+	if !l.Add("d", 2) {
+		t.Fatalf("an eviction should have occurred")
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{"a", "c", "d"}) {
+		t.Errorf("b should have been evicted: %v", l.Keys())
+	}
+
+	// The new entry is never a candidate, however large
+	l.Add("e", 100)
+	if !l.Contains("e") || l.Contains("c") {
+		t.Errorf("c should have been evicted: %v", l.Keys())
+	}
+}
+
+// Test the bundled LRU and MRU policies
+func TestLRU_BuiltinPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy EvictionPolicy
+		want   []interface{}
+	}{
+		{LRUPolicy{}, []interface{}{2, 3, 4}},
+		{MRUPolicy{}, []interface{}{1, 2, 4}},
+	} {
+		l, err := NewLRUWithOptions(3, WithEvictionPolicy(tc.policy))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := 1; i <= 4; i++ {
+			l.Add(i, i)
+		}
+		if !reflect.DeepEqual(l.Keys(), tc.want) {
+			t.Errorf("%T: bad keys: %v", tc.policy, l.Keys())
+		}
+	}
+}
+
+// Test that EvictionOrder predicts the victims actually evicted
+func TestLRU_EvictionOrder(t *testing.T) {
+	l, err := NewLRUWithEvict(5, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	l.Get(1)
+	l.Add(3, 30)
+
+	want := l.EvictionOrder()
+	if !reflect.DeepEqual(want, []interface{}{0, 2, 4, 1, 3}) || l.Len() != 5 {
+		t.Fatalf("bad eviction order: %v", want)
+	}
+	l.Pin(2)
+	if got := l.EvictionOrder(); !reflect.DeepEqual(got, []interface{}{0, 4, 1, 3}) {
+		t.Fatalf("pinned entries should be skipped: %v", got)
+	}
+	l.Unpin(2)
+	var got []interface{}
+	for l.Len() > 0 {
+		k, _, _ := l.RemoveOldest()
+		got = append(got, k)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad victims: %v, predicted %v", got, want)
+	}
+
+	// With a custom policy, compare against the victims of shrinking
+	var evicted []interface{}
+	p, err := NewLRUWithOptions(4, WithEvictionPolicy(largestPolicy{}),
+		WithEvictCallback(func(k, v interface{}) { evicted = append(evicted, k) }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for k, v := range map[string]int{"a": 3, "b": 9, "c": 1, "d": 5} {
+		p.Add(k, v)
+	}
+	want = p.EvictionOrder()
+	if !reflect.DeepEqual(want, []interface{}{"b", "d", "a", "c"}) || len(evicted) != 0 {
+		t.Fatalf("bad eviction order: %v", want)
+	}
+	for n := 3; n > 0; n-- {
+		p.Resize(n)
+	}
+	if !reflect.DeepEqual(evicted, want[:3]) {
+		t.Fatalf("bad victims: %v, predicted %v", evicted, want)
+	}
+}
@@ -0,0 +1,40 @@
+package simplelru
+
+import "errors"
+
+// defaultPressureSmoothing weighs roughly the last 20 adds.
+const defaultPressureSmoothing = 0.05
+
+// WithPressureSmoothing sets the weight alpha, in (0, 1], that each Add
+// carries in Pressure. Larger values react faster to a change in the
+// workload; smaller values smooth out bursts. It defaults to 0.05.
+func WithPressureSmoothing(alpha float64) Option {
+	return func(c *LRU) error {
+		if alpha <= 0 || alpha > 1 {
+			return errors.New("Must provide a smoothing factor in (0, 1]")
+		}
+		c.pressureAlpha = alpha
+		return nil
+	}
+}
+
+// Pressure returns an exponentially weighted moving average of the fraction
+// of adds that caused an eviction, between 0 and 1. A value near 1 means
+// almost every new entry displaces another, a sign that the cache is too
+// small for its working set; it can drive Resize decisions.
+func (c *LRUGeneric[K, V]) Pressure() float64 {
+	return c.pressure
+}
+
+// observePressure folds the outcome of one add into the pressure average.
+func (c *LRUGeneric[K, V]) observePressure(evicted bool) {
+	alpha := c.pressureAlpha
+	if alpha == 0 {
+		alpha = defaultPressureSmoothing
+	}
+	sample := 0.0
+	if evicted {
+		sample = 1
+	}
+	c.pressure += alpha * (sample - c.pressure)
+}
@@ -0,0 +1,50 @@
+package simplelru
+
+import "testing"
+
+// Test that pressure rises under heavy eviction and falls once it stops
+func TestLRU_Pressure(t *testing.T) {
+	l, err := NewLRUWithOptions(8, WithPressureSmoothing(0.2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Filling the cache and reusing its keys evicts nothing
+	for i := 0; i < 100; i++ {
+		l.Add(i%8, i)
+	}
+	if p := l.Pressure(); p != 0 {
+		t.Fatalf("bad pressure: %v", p)
+	}
+
+	// A working set larger than the cache evicts on every add
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+	high := l.Pressure()
+	if high < 0.9 {
+		t.Fatalf("pressure should have risen: %v", high)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Add(99, i)
+	}
+	if p := l.Pressure(); p >= high || p > 0.1 {
+		t.Fatalf("pressure should have fallen: %v", p)
+	}
+
+	// Inserts through GetOrAdd are sampled too
+	l.Purge()
+	for i := 0; i < 100; i++ {
+		l.GetOrAdd(1000+i, i)
+	}
+	if p := l.Pressure(); p < 0.9 {
+		t.Fatalf("GetOrAdd should have raised the pressure: %v", p)
+	}
+
+	for _, alpha := range []float64{0, -1, 1.5} {
+		if _, err := NewLRUWithOptions(8, WithPressureSmoothing(alpha)); err == nil {
+			t.Fatalf("should reject smoothing factor %v", alpha)
+		}
+	}
+}
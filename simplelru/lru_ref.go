@@ -0,0 +1,49 @@
+package simplelru
+
+// deferredEviction is an eviction whose callbacks wait for Release.
+type deferredEviction[K comparable, V any] struct {
+	kv     *entry[K, V]
+	reason EvictReason
+}
+
+// GetRef looks up a key's value like Get, and on a hit takes a reference to
+// the key that must be given back with Release. While a key has outstanding
+// references, the evict callbacks of its entries are deferred: an entry
+// evicted or removed meanwhile leaves the cache at once, but its callbacks
+// only fire once the last reference is released. This suits caches of shared
+// resources that the evict callback releases.
+func (c *LRUGeneric[K, V]) GetRef(key K) (value V, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	kv := c.lookup(key, &now)
+	if kv == nil {
+		return value, false
+	}
+	if c.refs == nil {
+		c.refs = make(map[K]int)
+		c.deferred = make(map[K][]deferredEviction[K, V])
+	}
+	c.refs[key]++
+	return kv.value, true
+}
+
+// Release gives back a reference taken by GetRef. Releasing the last
+// reference to a key fires the callbacks deferred for its evicted entries,
+// in the order they were evicted. Returns false if the key had no
+// outstanding references.
+func (c *LRUGeneric[K, V]) Release(key K) bool {
+	key = c.normalize(key)
+	if c.refs[key] == 0 {
+		return false
+	}
+	if c.refs[key]--; c.refs[key] > 0 {
+		return true
+	}
+	delete(c.refs, key)
+	deferred := c.deferred[key]
+	delete(c.deferred, key)
+	for _, d := range deferred {
+		c.evicted(d.kv, d.reason)
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that evicting a referenced entry defers onEvict until the last Release
+func TestLRU_GetRef(t *testing.T) {
+	var released []interface{}
+	l, err := NewLRUWithOptions(2, WithEvictCallback(func(k, v interface{}) {
+		released = append(released, v)
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "buf1")
+	l.Add(2, "buf2")
+	for i := 0; i < 2; i++ {
+		if v, ok := l.GetRef(1); !ok || v != "buf1" {
+			t.Fatalf("bad: %v, %v", v, ok)
+		}
+	}
+	if _, ok := l.GetRef(9); ok || l.Release(9) {
+		t.Fatalf("a miss should not take a reference")
+	}
+
+	// 2 is unreferenced, so it is released at once
+	l.Add(3, "buf3")
+	l.Add(4, "buf4")
+	if l.Contains(1) || !reflect.DeepEqual(released, []interface{}{"buf2"}) {
+		t.Fatalf("1 should be evicted but not released: %v", released)
+	}
+
+	if !l.Release(1) || len(released) != 1 {
+		t.Fatalf("1 is still referenced: %v", released)
+	}
+	if !l.Release(1) || !reflect.DeepEqual(released, []interface{}{"buf2", "buf1"}) {
+		t.Fatalf("the last release should fire onEvict: %v", released)
+	}
+	if l.Release(1) {
+		t.Fatalf("1 has no references left")
+	}
+
+	// Releasing a live entry fires nothing
+	l.GetRef(4)
+	if !l.Release(4) || len(released) != 2 || !l.Contains(4) {
+		t.Fatalf("bad: %v", released)
+	}
+}
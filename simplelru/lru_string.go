@@ -0,0 +1,17 @@
+package simplelru
+
+// StringLRU is a non-thread safe fixed size LRU cache with string keys. It
+// is an LRUGeneric, so its keys are stored in a map[string]*list.Element
+// without being converted to interface{}, which saves an allocation per key
+// on most paths compared with LRU. It shares the implementation of LRU and
+// so has every one of its methods, with string in place of interface{} for
+// keys.
+type StringLRU = LRUGeneric[string, interface{}]
+
+var _ LRUCacheGeneric[string, interface{}] = (*StringLRU)(nil)
+
+// NewStringLRU constructs a fixed size cache with string keys and the given
+// eviction callback.
+func NewStringLRU(size int, onEvict func(key string, value interface{})) (*StringLRU, error) {
+	return NewLRUGenericWithEvict[string, interface{}](size, onEvict)
+}
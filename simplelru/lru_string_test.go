@@ -0,0 +1,93 @@
+package simplelru
+
+import (
+	"strconv"
+	"testing"
+)
+
+// stringWorkload returns string keys and their values, boxed up front so
+// that the benchmarks only measure the cost of the keys.
+func stringWorkload() ([]string, []interface{}) {
+	keys := make([]string, 16384)
+	values := make([]interface{}, len(keys))
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		values[i] = keys[i]
+	}
+	return keys, values
+}
+
+func BenchmarkLRU_StringKeys(b *testing.B) {
+	l, err := NewLRUWithEvict(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys, values := stringWorkload()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := i % len(keys)
+		l.Add(keys[j], values[j])
+		l.Get(keys[j])
+	}
+}
+
+func BenchmarkStringLRU(b *testing.B) {
+	l, err := NewStringLRU(8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	keys, values := stringWorkload()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := i % len(keys)
+		l.Add(keys[j], values[j])
+		l.Get(keys[j])
+	}
+}
+
+func TestStringLRU(t *testing.T) {
+	evicted := 0
+	l, err := NewStringLRU(2, func(k string, v interface{}) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evicted++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("a", "a")
+	l.Add("b", "b")
+	l.Get("a")
+	l.Add("c", "c")
+	if evicted != 1 || l.Contains("b") {
+		t.Fatalf("b should have been evicted: %v", l.Keys())
+	}
+	if v, ok := l.Peek("a"); !ok || v != "a" {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if keys := l.Keys(); len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	if ok, _ := l.ContainsOrAdd("a", "x"); !ok {
+		t.Fatalf("a should be contained")
+	}
+	if prev, ok, _ := l.PeekOrAdd("c", "x"); !ok || prev != "c" {
+		t.Fatalf("bad previous value: %v, %v", prev, ok)
+	}
+	if values := l.Values(); len(values) != 2 || values[0] != "a" || values[1] != "c" {
+		t.Fatalf("bad values: %v", values)
+	}
+	if stats := l.Stats(); stats.Hits != 1 {
+		t.Fatalf("bad stats: %+v", stats)
+	}
+	if n := l.Resize(1); n != 1 || l.Cap() != 1 || evicted != 2 || l.Contains("a") {
+		t.Fatalf("a should have been evicted by Resize: %v", l.Keys())
+	}
+}
@@ -1,6 +1,17 @@
 package simplelru
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestLRU(t *testing.T) {
 	evictCounter := 0
@@ -165,3 +176,2411 @@ func TestLRU_Peek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+// Test that entries expire after their TTL
+func TestLRU_TTL(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithTTL(4, 20*time.Millisecond, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.AddWithTTL(2, 2, time.Hour)
+	l.AddWithTTL(3, 3, 0)
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("1 should be present: %v, %v", v, ok)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if l.Contains(1) {
+		t.Errorf("Contains should report 1 as expired")
+	}
+	if _, ok := l.Peek(1); ok {
+		t.Errorf("Peek should report 1 as expired")
+	}
+	if evictCounter != 0 {
+		t.Errorf("Contains and Peek should not evict: %v", evictCounter)
+	}
+	if _, ok := l.Get(1); ok {
+		t.Errorf("Get should report 1 as expired")
+	}
+	if evictCounter != 1 || l.Len() != 2 {
+		t.Errorf("Get should have evicted 1: %v, %v", evictCounter, l.Len())
+	}
+	if !l.Contains(2) || !l.Contains(3) {
+		t.Errorf("2 and 3 should not have expired")
+	}
+}
+
+// Test that re-adding an expired key evicts the stale entry
+func TestLRU_TTL_AddExpired(t *testing.T) {
+	var evicted []interface{}
+	onEvicted := func(k interface{}, v interface{}) {
+		evicted = append(evicted, v)
+	}
+	l, err := NewLRUWithTTL(2, 0, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithTTL(1, "old", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	l.Add(1, "new")
+
+	if len(evicted) != 1 || evicted[0] != "old" {
+		t.Fatalf("stale entry should have been evicted: %v", evicted)
+	}
+	if v, ok := l.Get(1); !ok || v != "new" {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+
+	// A non-TTL Add over a live TTL entry clears its deadline
+	l.AddWithTTL(2, 2, 10*time.Millisecond)
+	l.Add(2, 2)
+	time.Sleep(20 * time.Millisecond)
+	if !l.Contains(2) {
+		t.Fatalf("2 should no longer expire")
+	}
+
+	l.AddWithTTL(3, 3, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if v, _, added := l.GetOrAdd(3, 4); v != 4 || !added {
+		t.Fatalf("expired entry should be replaced: %v, %v", v, added)
+	}
+}
+
+// Test that Resize evicts the oldest entries when shrinking
+func TestLRU_Resize(t *testing.T) {
+	onEvictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		onEvictCounter++
+	}
+	l, err := NewLRUWithEvict(2, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Downsize
+	l.Add(1, 1)
+	l.Add(2, 2)
+	evicted := l.Resize(1)
+	if evicted != 1 {
+		t.Errorf("1 element should have been evicted: %v", evicted)
+	}
+	if onEvictCounter != 1 {
+		t.Errorf("onEvicted should have been called 1 time: %v", onEvictCounter)
+	}
+	if l.Contains(1) {
+		t.Errorf("Element 1 should have been evicted")
+	}
+
+	l.Add(3, 3)
+	if l.Contains(2) {
+		t.Errorf("Element 2 should have been evicted")
+	}
+
+	if l.Cap() != 1 {
+		t.Errorf("bad cap: %v", l.Cap())
+	}
+
+	// Upsize
+	evicted = l.Resize(2)
+	if evicted != 0 {
+		t.Errorf("0 elements should have been evicted: %v", evicted)
+	}
+
+	l.Add(4, 4)
+	if !l.Contains(3) || !l.Contains(4) {
+		t.Errorf("Cache should have contained 2 elements")
+	}
+
+	// Non-positive sizes are rejected
+	if evicted = l.Resize(0); evicted != 0 || l.Len() != 2 {
+		t.Errorf("Resize(0) should leave the cache unchanged: %v, %v", evicted, l.Len())
+	}
+	l.Add(5, 5)
+	if l.Len() != 2 {
+		t.Errorf("size should still be 2: %v", l.Len())
+	}
+}
+
+// Test that Stats tracks hits, misses, evictions and insertions
+func TestLRU_Stats(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(2, 2) // overwrite is not an insertion
+	l.Add(3, 3) // evicts 1
+	l.Get(2)
+	l.Get(3)
+	l.Get(1)
+	l.GetOrAdd(3, 3) // hit
+	l.GetOrAdd(4, 4) // miss, insertion and eviction
+	l.Peek(4)        // not counted
+
+	want := Stats{Hits: 3, Misses: 2, Evictions: 2, Insertions: 4}
+	if s := l.Stats(); s != want {
+		t.Fatalf("bad stats: %+v != %+v", s, want)
+	}
+	if r := l.Stats().HitRatio(); r != 0.6 {
+		t.Errorf("bad hit ratio: %v", r)
+	}
+
+	l.ResetStats()
+	if s := l.Stats(); s != (Stats{}) {
+		t.Errorf("stats should be zero: %+v", s)
+	}
+	if r := l.Stats().HitRatio(); r != 0 {
+		t.Errorf("bad hit ratio: %v", r)
+	}
+}
+
+// Test that Values lines up with Keys and doesn't update recent-ness
+func TestLRU_Values(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "one")
+	l.Add(2, "two")
+	l.Add(3, "three")
+	l.Get(1)
+
+	keys := l.Keys()
+	values := l.Values()
+	if len(keys) != len(values) {
+		t.Fatalf("length mismatch: %v != %v", len(keys), len(values))
+	}
+	for i, k := range keys {
+		if v, _ := l.Peek(k); values[i] != v {
+			t.Errorf("value %d doesn't match key %v: %v != %v", i, k, values[i], v)
+		}
+	}
+	if values[0] != "two" || values[2] != "one" {
+		t.Errorf("bad order: %v", values)
+	}
+
+	l.Add(4, "four")
+	if l.Contains(2) {
+		t.Errorf("Values should not have updated recent-ness of 2")
+	}
+}
+
+// Test that ContainsOrAdd doesn't update recent-ness on a hit
+func TestLRU_ContainsOrAdd(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	ok, evicted := l.ContainsOrAdd(1, 10)
+	if !ok || evicted {
+		t.Errorf("1 should be contained without eviction: %v, %v", ok, evicted)
+	}
+	if v, _ := l.Peek(1); v != 1 {
+		t.Errorf("value of 1 should be unchanged: %v", v)
+	}
+
+	ok, evicted = l.ContainsOrAdd(3, 3)
+	if ok || !evicted {
+		t.Errorf("3 should be added with an eviction: %v, %v", ok, evicted)
+	}
+	if l.Contains(1) {
+		t.Errorf("ContainsOrAdd should not have updated recent-ness of 1")
+	}
+	if !l.Contains(3) {
+		t.Errorf("3 should be contained")
+	}
+}
+
+// Test that PeekOrAdd doesn't update recent-ness or fire onAcquire on a hit
+func TestLRU_PeekOrAdd(t *testing.T) {
+	acquireCounter := 0
+	onAcquired := func(k interface{}, v interface{}) {
+		acquireCounter++
+	}
+	l, err := NewLRUWithAcquireAndEvict(2, onAcquired, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	prev, ok, evicted := l.PeekOrAdd(1, 10)
+	if prev != 1 || !ok || evicted {
+		t.Errorf("1 should be contained: %v, %v, %v", prev, ok, evicted)
+	}
+	if acquireCounter != 2 {
+		t.Errorf("onAcquire should not fire on a hit: %v", acquireCounter)
+	}
+
+	prev, ok, evicted = l.PeekOrAdd(3, 3)
+	if prev != nil || ok || !evicted {
+		t.Errorf("3 should be added with an eviction: %v, %v, %v", prev, ok, evicted)
+	}
+	if l.Contains(1) {
+		t.Errorf("PeekOrAdd should not have updated recent-ness of 1")
+	}
+}
+
+// Test that GetOrLoad only calls loader on a miss and doesn't cache errors
+func TestLRU_GetOrLoad(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	loads := 0
+	loader := func(k interface{}) (interface{}, error) {
+		loads++
+		return k.(int) * 10, nil
+	}
+
+	if v, err := l.GetOrLoad(1, loader); err != nil || v != 10 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if v, err := l.GetOrLoad(1, loader); err != nil || v != 10 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if loads != 1 {
+		t.Errorf("loader should have been called once: %v", loads)
+	}
+
+	errLoad := errors.New("load failed")
+	v, err := l.GetOrLoad(2, func(k interface{}) (interface{}, error) {
+		return nil, errLoad
+	})
+	if err != errLoad || v != nil {
+		t.Errorf("bad: %v, %v", v, err)
+	}
+	if l.Contains(2) {
+		t.Errorf("failed load should not be cached")
+	}
+}
+
+// Test that Range walks oldest to newest and honours early termination
+func TestLRU_Range(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i*10)
+	}
+	l.Get(0)
+
+	var keys []interface{}
+	l.Range(func(k, v interface{}) bool {
+		if v != k.(int)*10 {
+			t.Errorf("bad value for %v: %v", k, v)
+		}
+		keys = append(keys, k)
+		return true
+	})
+	want := []interface{}{1, 2, 3, 0}
+	if len(keys) != len(want) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("bad keys: %v", keys)
+		}
+	}
+
+	visited := 0
+	l.Range(func(k, v interface{}) bool {
+		visited++
+		return visited < 2
+	})
+	if visited != 2 {
+		t.Errorf("Range should have stopped after 2 entries: %v", visited)
+	}
+
+	// Removing the current key is allowed
+	l.Range(func(k, v interface{}) bool {
+		if k.(int)%2 == 0 {
+			l.Remove(k)
+		}
+		return true
+	})
+	if l.Len() != 2 || l.Contains(0) || l.Contains(2) {
+		t.Errorf("even keys should have been removed: %v", l.Keys())
+	}
+
+	// Range must not update recent-ness
+	l.Add(4, 40)
+	l.Add(5, 50)
+	l.Add(6, 60)
+	if l.Contains(1) {
+		t.Errorf("Range should not have updated recent-ness of 1")
+	}
+}
+
+// Test that a cost-bounded cache evicts by total cost
+func TestLRU_Cost(t *testing.T) {
+	var evicted []interface{}
+	onEvicted := func(k interface{}, v interface{}) {
+		evicted = append(evicted, k)
+	}
+	costFunc := func(k interface{}, v interface{}) int64 {
+		return int64(len(v.(string)))
+	}
+	l, err := NewLRUWithCost(10, costFunc, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "aaaa")
+	l.Add(2, "bbb")
+	l.Add(3, "cc")
+	if l.Cost() != 9 || l.Len() != 3 {
+		t.Fatalf("bad cost or len: %v, %v", l.Cost(), l.Len())
+	}
+
+	// Needs two evictions to fit
+	if !l.Add(4, "dddddd") {
+		t.Errorf("should have an eviction")
+	}
+	if l.Cost() != 8 || len(evicted) != 2 || evicted[0] != 1 || evicted[1] != 2 {
+		t.Errorf("bad cost or evictions: %v, %v", l.Cost(), evicted)
+	}
+
+	// Overwriting adjusts the total by the delta and may evict
+	l.Add(3, "c")
+	if l.Cost() != 7 {
+		t.Errorf("bad cost after shrinking overwrite: %v", l.Cost())
+	}
+	if !l.Add(3, "ccccc") {
+		t.Errorf("growing overwrite should have an eviction")
+	}
+	if l.Cost() != 5 || l.Contains(4) || !l.Contains(3) {
+		t.Errorf("bad state after growing overwrite: %v, %v", l.Cost(), l.Keys())
+	}
+
+	l.Remove(3)
+	if l.Cost() != 0 {
+		t.Errorf("bad cost after remove: %v", l.Cost())
+	}
+	l.Add(5, "ee")
+	l.Purge()
+	if l.Cost() != 0 {
+		t.Errorf("bad cost after purge: %v", l.Cost())
+	}
+
+	if _, err := NewLRUWithCost(0, costFunc, nil); err == nil {
+		t.Errorf("non-positive max cost should fail")
+	}
+	if _, err := NewLRUWithCost(10, nil, nil); err == nil {
+		t.Errorf("nil cost func should fail")
+	}
+}
+
+// Test that Keys is ordered oldest to newest, so Keys()[0] is the next
+// eviction candidate
+func TestLRU_KeysOrder(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+
+	keys := l.Keys()
+	want := []interface{}{2, 3, 1}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("bad order: %v != %v", keys, want)
+		}
+	}
+
+	if k, _, _ := l.GetOldest(); k != keys[0] {
+		t.Errorf("oldest key should be Keys()[0]: %v != %v", k, keys[0])
+	}
+	var evicted interface{}
+	l.onEvict = func(k interface{}, v interface{}) {
+		evicted = k
+	}
+	l.Add(4, 4)
+	if evicted != keys[0] {
+		t.Errorf("Keys()[0] should have been evicted: %v != %v", evicted, keys[0])
+	}
+}
+
+// Test that PopNewest drains the cache newest first
+func TestLRU_PopNewest(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithEvict(3, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, _, ok := l.PopNewest(); ok {
+		t.Fatalf("empty cache should have nothing to pop")
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+
+	for _, want := range []int{1, 3, 2} {
+		k, v, ok := l.PopNewest()
+		if !ok || k != want || v != want {
+			t.Fatalf("bad pop: %v, %v, %v, want %v", k, v, ok, want)
+		}
+		if l.Contains(k) {
+			t.Fatalf("%v should have been removed", k)
+		}
+	}
+	if l.Len() != 0 || evictCounter != 3 {
+		t.Errorf("bad len or evict count: %v, %v", l.Len(), evictCounter)
+	}
+	if _, _, ok := l.PopNewest(); ok {
+		t.Errorf("drained cache should have nothing to pop")
+	}
+}
+
+// Test that Restore rebuilds the recency order captured by Snapshot
+func TestLRU_SnapshotRestore(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, "one")
+	l.Add(2, "two")
+	l.Add(3, "three")
+	l.Get(1)
+
+	snap := l.Snapshot()
+	want := []Entry{{Key: 1, Value: "one"}, {Key: 3, Value: "three"}, {Key: 2, Value: "two"}}
+	if len(snap) != 3 {
+		t.Fatalf("bad snapshot: %v", snap)
+	}
+	for i := range want {
+		if snap[i] != want[i] {
+			t.Fatalf("bad snapshot: %v != %v", snap, want)
+		}
+	}
+
+	r, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.Add(9, "stale")
+	r.Restore(snap)
+	if r.Contains(9) {
+		t.Errorf("Restore should clear existing contents")
+	}
+	rk, lk := r.Keys(), l.Keys()
+	for i := range lk {
+		if rk[i] != lk[i] {
+			t.Fatalf("restored order differs: %v != %v", rk, lk)
+		}
+	}
+
+	// A smaller cache keeps the newest entries
+	small, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	small.Restore(snap)
+	if small.Len() != 2 || !small.Contains(1) || !small.Contains(3) {
+		t.Errorf("newest entries should be kept: %v", small.Keys())
+	}
+}
+
+// Test that snapshots skip expired entries and keep the remaining TTL
+func TestLRU_SnapshotTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := WithClock(func() time.Time { return now })
+	l, err := NewLRUWithOptions(4, clock)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.AddWithTTL("dead", 1, time.Minute)
+	l.AddWithTTL("ttl", 2, time.Hour)
+	l.Add("forever", 3)
+	now = now.Add(2 * time.Minute)
+
+	want := []Entry{{Key: "forever", Value: 3}, {Key: "ttl", Value: 2, TTL: 58 * time.Minute}}
+	if snap := l.Snapshot(); !reflect.DeepEqual(snap, want) {
+		t.Fatalf("bad snapshot: %v", snap)
+	}
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if strings.Contains(string(data), "dead") {
+		t.Fatalf("expired entry should not be encoded: %s", data)
+	}
+	r, err := NewLRUWithOptions(4, clock)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ttl, ok := r.GetWithTTL("ttl"); !ok || v != 2.0 || ttl != 58*time.Minute {
+		t.Fatalf("remaining TTL should survive a round trip: %v, %v, %v", v, ttl, ok)
+	}
+	now = now.Add(time.Hour)
+	if r.Contains("ttl") || !r.Contains("forever") {
+		t.Fatalf("restored entry should expire on time: %v", r.Keys())
+	}
+}
+
+// Test that RemoveExpired reclaims only expired entries
+func TestLRU_RemoveExpired(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithTTL(4, 0, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithTTL(1, 1, 10*time.Millisecond)
+	l.Add(2, 2)
+	l.AddWithTTL(3, 3, 10*time.Millisecond)
+	l.AddWithTTL(4, 4, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	if removed := l.RemoveExpired(); removed != 2 {
+		t.Errorf("2 entries should have been removed: %v", removed)
+	}
+	if evictCounter != 2 || l.Len() != 2 || !l.Contains(2) || !l.Contains(4) {
+		t.Errorf("bad state: %v, %v", evictCounter, l.Keys())
+	}
+}
+
+// Test that RemoveFunc removes exactly the matching entries
+func TestLRU_RemoveFunc(t *testing.T) {
+	var evicted []interface{}
+	onEvicted := func(k interface{}, v interface{}) {
+		evicted = append(evicted, k)
+	}
+	l, err := NewLRUWithEvict(8, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i%2 == 0)
+	}
+
+	removed := l.RemoveFunc(func(k, v interface{}) bool {
+		return v.(bool)
+	})
+	if removed != 4 || len(evicted) != 4 {
+		t.Fatalf("4 entries should have been removed: %v, %v", removed, evicted)
+	}
+	for _, k := range l.Keys() {
+		if k.(int)%2 == 0 {
+			t.Errorf("%v should have been removed", k)
+		}
+	}
+	if l.Len() != 4 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	if removed := l.RemoveFunc(func(k, v interface{}) bool { return false }); removed != 0 {
+		t.Errorf("nothing should have been removed: %v", removed)
+	}
+}
+
+// Test that Touch changes eviction order without firing onAcquire
+func TestLRU_Touch(t *testing.T) {
+	acquireCounter := 0
+	onAcquired := func(k interface{}, v interface{}) {
+		acquireCounter++
+	}
+	l, err := NewLRUWithAcquireAndEvict(2, onAcquired, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Touch(1) {
+		t.Errorf("1 should be present")
+	}
+	if l.Touch(3) {
+		t.Errorf("3 should not be present")
+	}
+	if acquireCounter != 2 {
+		t.Errorf("Touch should not fire onAcquire: %v", acquireCounter)
+	}
+
+	l.Add(3, 3)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Errorf("Touch should have protected 1 from eviction: %v", l.Keys())
+	}
+}
+
+// Test that UpdateValue replaces values without changing eviction order
+func TestLRU_UpdateValue(t *testing.T) {
+	acquireCounter := 0
+	onAcquired := func(k interface{}, v interface{}) {
+		acquireCounter++
+	}
+	l, err := NewLRUWithAcquireAndEvict(2, onAcquired, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.UpdateValue(1, 10) {
+		t.Errorf("1 should be present")
+	}
+	if l.UpdateValue(3, 3) || l.Contains(3) {
+		t.Errorf("UpdateValue should not insert absent keys")
+	}
+	if v, _ := l.Peek(1); v != 10 {
+		t.Errorf("bad value: %v", v)
+	}
+	if acquireCounter != 2 {
+		t.Errorf("UpdateValue should not fire onAcquire: %v", acquireCounter)
+	}
+
+	keys := l.Keys()
+	if keys[0] != 1 || keys[1] != 2 {
+		t.Errorf("eviction order should be unchanged: %v", keys)
+	}
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Errorf("1 should still have been the oldest")
+	}
+}
+
+// Test that PeekAndRemove consumes the entry
+func TestLRU_PeekAndRemove(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithEvict(2, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("token", 42)
+	if v, ok := l.PeekAndRemove("token"); !ok || v != 42 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if l.Contains("token") || l.Len() != 0 {
+		t.Errorf("token should be absent after PeekAndRemove")
+	}
+	if evictCounter != 1 {
+		t.Errorf("onEvict should have fired once: %v", evictCounter)
+	}
+	if v, ok := l.PeekAndRemove("token"); ok || v != nil {
+		t.Errorf("second PeekAndRemove should find nothing: %v, %v", v, ok)
+	}
+}
+
+// Test that the miss callback fires for Get and GetOrAdd misses only
+func TestLRU_MissCallback(t *testing.T) {
+	var missed []interface{}
+	evictCounter := 0
+	l, err := NewLRUWithOptions(2,
+		WithMissCallback(func(k interface{}) {
+			missed = append(missed, k)
+		}),
+		WithEvictCallback(func(k, v interface{}) {
+			evictCounter++
+		}),
+	)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Get(1)
+	l.Get(2)
+	l.GetOrAdd(3, 3)
+	l.GetOrAdd(3, 3)
+	l.Peek(4)
+	l.Contains(5)
+
+	if len(missed) != 2 || missed[0] != 2 || missed[1] != 3 {
+		t.Errorf("bad misses: %v", missed)
+	}
+
+	l.Add(4, 4)
+	if evictCounter != 1 {
+		t.Errorf("bad evict count: %v", evictCounter)
+	}
+
+	if _, err := NewLRUWithOptions(0); err == nil {
+		t.Errorf("non-positive size should fail")
+	}
+}
+
+// Test that GetMulti splits hits from misses and promotes in order
+func TestLRU_GetMulti(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if evicted := l.AddMulti(map[interface{}]interface{}{1: 1, 2: 2, 3: 3}); evicted != 0 {
+		t.Errorf("nothing should be evicted: %v", evicted)
+	}
+	l.Add(4, 4)
+
+	found, missing := l.GetMulti([]interface{}{2, 5, 1, 6})
+	if len(found) != 2 || found[1] != 1 || found[2] != 2 {
+		t.Errorf("bad found: %v", found)
+	}
+	if len(missing) != 2 || missing[0] != 5 || missing[1] != 6 {
+		t.Errorf("bad missing: %v", missing)
+	}
+
+	keys := l.Keys()
+	if keys[2] != 2 || keys[3] != 1 {
+		t.Errorf("found keys should be promoted in order: %v", keys)
+	}
+
+	if evicted := l.AddMulti(map[interface{}]interface{}{7: 7, 8: 8}); evicted != 2 {
+		t.Errorf("2 adds should have evicted: %v", evicted)
+	}
+	if !l.Contains(1) || !l.Contains(2) {
+		t.Errorf("promoted keys should survive: %v", l.Keys())
+	}
+}
+
+// Test that Cap reports the configured size
+func TestLRU_Cap(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	if l.Cap() != 3 || l.Len() != 1 {
+		t.Errorf("bad cap or len: %v, %v", l.Cap(), l.Len())
+	}
+}
+
+// Test that a clone is independent of the original
+func TestLRU_Clone(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithEvict(3, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+
+	c := l.Clone()
+	if c.Cap() != l.Cap() || c.Len() != l.Len() {
+		t.Fatalf("bad clone: %v, %v", c.Cap(), c.Len())
+	}
+	ck, lk := c.Keys(), l.Keys()
+	for i := range lk {
+		if ck[i] != lk[i] {
+			t.Fatalf("bad clone order: %v != %v", ck, lk)
+		}
+	}
+
+	c.Add(4, 4)
+	c.Remove(3)
+	c.Get(2)
+	c.UpdateValue(1, 10)
+	if evictCounter != 2 {
+		t.Errorf("clone should share callbacks: %v", evictCounter)
+	}
+
+	if l.Len() != 3 {
+		t.Errorf("original len changed: %v", l.Len())
+	}
+	lk2 := l.Keys()
+	for i := range lk {
+		if lk2[i] != lk[i] {
+			t.Errorf("original order changed: %v != %v", lk2, lk)
+		}
+	}
+	if v, _ := l.Peek(1); v != 1 {
+		t.Errorf("original value changed: %v", v)
+	}
+}
+
+// Test that PeekNewest returns the front entry without changing order
+func TestLRU_PeekNewest(t *testing.T) {
+	l, err := NewLRUWithEvict(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, ok := l.PeekNewest(); ok {
+		t.Fatalf("empty cache should have no newest entry")
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+
+	before := l.Keys()
+	k, v, ok := l.PeekNewest()
+	if !ok || k != 1 || v != 1 {
+		t.Errorf("bad newest: %v, %v, %v", k, v, ok)
+	}
+	after := l.Keys()
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("PeekNewest changed order: %v != %v", after, before)
+		}
+	}
+	if k, _, _ := l.GetOldest(); k != 2 {
+		t.Errorf("bad oldest: %v", k)
+	}
+}
+
+// Test that GetOrLoadContext respects cancellation
+func TestLRU_GetOrLoadContext(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	loads := 0
+	loader := func(ctx context.Context, k interface{}) (interface{}, error) {
+		loads++
+		return k, nil
+	}
+
+	if v, err := l.GetOrLoadContext(context.Background(), 1, loader); err != nil || v != 1 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Hits don't need the context
+	if v, err := l.GetOrLoadContext(ctx, 1, loader); err != nil || v != 1 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if _, err := l.GetOrLoadContext(ctx, 2, loader); err != context.Canceled {
+		t.Fatalf("cancelled context should fail: %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("loader should not run with a done context: %v", loads)
+	}
+
+	// Cancelled while loading
+	ctx, cancel = context.WithCancel(context.Background())
+	_, err = l.GetOrLoadContext(ctx, 3, func(ctx context.Context, k interface{}) (interface{}, error) {
+		cancel()
+		return k, nil
+	})
+	if err != context.Canceled || l.Contains(3) {
+		t.Errorf("value loaded after cancellation should not be cached: %v", err)
+	}
+}
+
+// Test that a negative entry suppresses loads until it expires
+func TestLRU_Negative(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	loads := 0
+	loader := func(k interface{}) (interface{}, error) {
+		loads++
+		return "found", nil
+	}
+
+	l.AddNegative("k", 20*time.Millisecond)
+	if v, ok, negative := l.Lookup("k"); v != nil || !ok || !negative {
+		t.Fatalf("bad lookup: %v, %v, %v", v, ok, negative)
+	}
+	if _, ok, negative := l.Lookup("other"); ok || negative {
+		t.Fatalf("bad lookup of a miss: %v, %v", ok, negative)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := l.GetOrLoad("k", loader); err != ErrNegativeEntry {
+			t.Fatalf("negative entry should be reported: %v", err)
+		}
+	}
+	if loads != 0 {
+		t.Fatalf("loader should not run for a negative entry: %v", loads)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if v, err := l.GetOrLoad("k", loader); err != nil || v != "found" {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if loads != 1 {
+		t.Fatalf("loader should run once the negative entry expires: %v", loads)
+	}
+	if _, ok, negative := l.Lookup("k"); !ok || negative {
+		t.Errorf("loaded value should replace the negative entry")
+	}
+}
+
+// Test that sliding expiration keeps accessed entries alive while absolute
+// expiration does not
+func TestLRU_ExpirationMode(t *testing.T) {
+	sliding, err := NewLRUWithTTL(2, 30*time.Millisecond, nil,
+		WithExpirationMode(SlidingExpiration))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	absolute, err := NewLRUWithTTL(2, 30*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sliding.Add(1, 1)
+	absolute.Add(1, 1)
+	for i := 0; i < 6; i++ {
+		time.Sleep(10 * time.Millisecond)
+		sliding.Get(1)
+		absolute.Get(1)
+	}
+
+	if !sliding.Contains(1) {
+		t.Errorf("sliding expiration should keep 1 alive")
+	}
+	if absolute.Contains(1) {
+		t.Errorf("absolute expiration should let 1 expire")
+	}
+
+	if _, err := NewLRUWithTTL(2, time.Second, nil, WithExpirationMode(ExpirationMode(7))); err == nil {
+		t.Errorf("invalid mode should fail")
+	}
+}
+
+// Test that GetWithTTL reports a shrinking remaining lifetime
+func TestLRU_GetWithTTL(t *testing.T) {
+	l, err := NewLRUWithTTL(2, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.AddWithTTL(2, 2, 0)
+
+	v, first, ok := l.GetWithTTL(1)
+	if !ok || v != 1 {
+		t.Fatalf("1 should be present: %v, %v", v, ok)
+	}
+	if first <= 0 || first > time.Hour {
+		t.Fatalf("bad ttl: %v", first)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, second, _ := l.GetWithTTL(1); second >= first {
+		t.Errorf("remaining ttl should decrease: %v >= %v", second, first)
+	}
+
+	if _, ttl, ok := l.GetWithTTL(2); !ok || ttl != 0 {
+		t.Errorf("entry without expiry should report zero ttl: %v, %v", ttl, ok)
+	}
+	if _, ttl, ok := l.GetWithTTL(3); ok || ttl != 0 {
+		t.Errorf("missing key should not be reported: %v, %v", ttl, ok)
+	}
+}
+
+// Test that WithFireAcquireOnGet(false) limits onAcquire to inserts
+func TestLRU_FireAcquireOnGet(t *testing.T) {
+	for _, fire := range []bool{true, false} {
+		acquireCounter := 0
+		onAcquired := func(k interface{}, v interface{}) {
+			acquireCounter++
+		}
+		l, err := NewLRUWithOptions(2,
+			WithAcquireCallback(onAcquired),
+			WithFireAcquireOnGet(fire))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		l.Add(1, 1)
+		l.Add(1, 10)
+		l.Get(1)
+		l.GetOrAdd(1, 1)
+		l.Get(2)
+
+		want := 2
+		if fire {
+			want = 4
+		}
+		if acquireCounter != want {
+			t.Errorf("fire=%v: bad acquire count: %v", fire, acquireCounter)
+		}
+	}
+}
+
+// Test that a full cache in reject mode refuses new keys but accepts updates
+func TestLRU_RejectOnFull(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRUWithOptions(2,
+		WithRejectOnFull(),
+		WithEvictCallback(func(k, v interface{}) { evictCounter++ }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if inserted, evicted := l.TryAdd(i, i); !inserted || evicted {
+			t.Fatalf("%d should be inserted: %v, %v", i, inserted, evicted)
+		}
+	}
+	if inserted, evicted := l.TryAdd(2, 2); inserted || evicted {
+		t.Errorf("2 should be rejected: %v, %v", inserted, evicted)
+	}
+	if l.Add(3, 3) || l.Contains(3) {
+		t.Errorf("Add should not insert 3 into a full cache")
+	}
+	if inserted, evicted := l.TryAdd(0, 10); !inserted || evicted {
+		t.Errorf("updating 0 should succeed: %v, %v", inserted, evicted)
+	}
+	if v, _ := l.Peek(0); v != 10 {
+		t.Errorf("bad value for 0: %v", v)
+	}
+	if l.Len() != 2 || evictCounter != 0 {
+		t.Errorf("nothing should have been evicted: %v, %v", l.Len(), evictCounter)
+	}
+
+	l.Remove(1)
+	if inserted, _ := l.TryAdd(2, 2); !inserted {
+		t.Errorf("2 should be inserted once there is room")
+	}
+}
+
+// Test that AddEx tells new keys apart from overwrites
+func TestLRU_AddEx(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewLRUWithOptions(2, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if inserted, evicted := l.AddEx(1, 1); !inserted || evicted {
+		t.Fatalf("1 should be inserted: %v, %v", inserted, evicted)
+	}
+	if inserted, evicted := l.AddEx(1, 10); inserted || evicted {
+		t.Fatalf("1 should be overwritten: %v, %v", inserted, evicted)
+	}
+	if v, _ := l.Peek(1); v != 10 {
+		t.Fatalf("bad value for 1: %v", v)
+	}
+	if inserted, evicted := l.AddEx(2, 2); !inserted || evicted {
+		t.Fatalf("2 should be inserted: %v, %v", inserted, evicted)
+	}
+	if inserted, evicted := l.AddEx(3, 3); !inserted || !evicted {
+		t.Fatalf("3 should be inserted with an eviction: %v, %v", inserted, evicted)
+	}
+	if l.Contains(1) {
+		t.Fatalf("1 should have been evicted")
+	}
+
+	// Replacing an expired entry counts as a new key
+	l.AddWithTTL(4, 4, time.Second)
+	now = now.Add(2 * time.Second)
+	if inserted, _ := l.AddEx(4, 40); !inserted {
+		t.Fatalf("expired 4 should count as absent")
+	}
+}
+
+// Test that RemoveOlderThan sweeps by insertion time, not recency
+func TestLRU_RemoveOlderThan(t *testing.T) {
+	var evicted []interface{}
+	now := time.Unix(1000, 0)
+	l, err := NewLRUWithOptions(8,
+		WithEvictCallback(func(k, v interface{}) {
+			evicted = append(evicted, k)
+		}),
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		now = now.Add(time.Minute)
+	}
+	l.Get(0) // recency does not protect an old entry
+	l.Add(1, 10)
+
+	// Ages are now 0: 4m, 1: 0m, 2: 2m, 3: 1m
+	if removed := l.RemoveOlderThan(90 * time.Second); removed != 2 {
+		t.Fatalf("bad removed: %v", removed)
+	}
+	if l.Contains(0) || l.Contains(2) || !l.Contains(1) || !l.Contains(3) {
+		t.Errorf("bad keys: %v", l.Keys())
+	}
+	if len(evicted) != 2 {
+		t.Errorf("onEvict should fire for swept entries: %v", evicted)
+	}
+	if removed := l.RemoveOlderThan(time.Hour); removed != 0 {
+		t.Errorf("nothing should be that old: %v", removed)
+	}
+}
+
+// Test that an injected clock drives expiry exactly
+func TestLRU_WithClock(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l, err := NewLRUWithTTL(4, time.Minute, nil,
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	now = now.Add(time.Minute - time.Nanosecond)
+	if _, ttl, ok := l.GetWithTTL(1); !ok || ttl != time.Nanosecond {
+		t.Fatalf("1 should have 1ns left: %v, %v", ttl, ok)
+	}
+	now = now.Add(time.Nanosecond)
+	if l.Contains(1) {
+		t.Fatalf("1 should have expired")
+	}
+
+	if _, err := NewLRUWithOptions(4, WithClock(nil)); err == nil {
+		t.Errorf("nil clock should fail")
+	}
+}
+
+// Test that ReplaceOrAdd returns the replaced value and promotes the key
+func TestLRU_ReplaceOrAdd(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	prev, existed, evicted := l.ReplaceOrAdd(1, 1)
+	if prev != nil || existed || evicted {
+		t.Errorf("1 should be inserted: %v, %v, %v", prev, existed, evicted)
+	}
+	l.Add(2, 2)
+
+	prev, existed, evicted = l.ReplaceOrAdd(1, 10)
+	if prev != 1 || !existed || evicted {
+		t.Errorf("1 should be replaced: %v, %v, %v", prev, existed, evicted)
+	}
+	if v, _ := l.Peek(1); v != 10 {
+		t.Errorf("bad value for 1: %v", v)
+	}
+
+	prev, existed, evicted = l.ReplaceOrAdd(3, 3)
+	if prev != nil || existed || !evicted {
+		t.Errorf("3 should be inserted with an eviction: %v, %v, %v", prev, existed, evicted)
+	}
+	if l.Contains(2) || !l.Contains(1) {
+		t.Errorf("replacing 1 should have promoted it: %v", l.Keys())
+	}
+}
+
+// Test that AddIfAbsent keeps the first value stored for a key
+func TestLRU_AddIfAbsent(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if actual, loaded := l.AddIfAbsent(1, "a"); actual != "a" || loaded {
+		t.Errorf("a should be stored: %v, %v", actual, loaded)
+	}
+	l.Add(2, 2)
+	if actual, loaded := l.AddIfAbsent(1, "b"); actual != "a" || !loaded {
+		t.Errorf("a should be loaded: %v, %v", actual, loaded)
+	}
+
+	// The hit must not have promoted 1
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Errorf("AddIfAbsent should not update recent-ness of 1")
+	}
+}
+
+// Test that Drain hands back every entry and leaves the cache empty
+func TestLRU_Drain(t *testing.T) {
+	var evicted []interface{}
+	l, err := NewLRUWithEvict(4, func(k, v interface{}) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.Add(i, i*10)
+	}
+	l.AddWithTTL(3, 30, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	entries := l.Drain()
+	want := []Entry{{Key: 2, Value: 20}, {Key: 1, Value: 10}, {Key: 0, Value: 0}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("bad entries: %v", entries)
+	}
+	if l.Len() != 0 || len(l.Keys()) != 0 {
+		t.Errorf("cache should be empty: %v", l.Keys())
+	}
+	if len(evicted) != 4 {
+		t.Errorf("onEvict should fire for every entry: %v", evicted)
+	}
+
+	l.Add(5, 5)
+	if !l.Contains(5) {
+		t.Errorf("cache should be usable after Drain")
+	}
+}
+
+// Test that a zero-value LRU works as an unbounded cache
+func TestLRU_ZeroValue(t *testing.T) {
+	var l LRU
+	if l.Len() != 0 || len(l.Keys()) != 0 {
+		t.Fatalf("zero value should be empty")
+	}
+	if _, ok := l.Get(1); ok {
+		t.Fatalf("should contain nothing")
+	}
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Fatalf("should contain nothing")
+	}
+
+	for i := 0; i < 100; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("zero value should not evict")
+		}
+	}
+	if v, ok := l.Get(50); !ok || v != 50 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if k, _, _ := l.GetOldest(); k != 0 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if !l.Remove(0) || l.Len() != 99 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if clone := l.Clone(); clone.Len() != 99 {
+		t.Fatalf("bad clone len: %v", clone.Len())
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+// Test that ApproxBytes keeps a running total through overwrites and removals
+func TestLRU_ApproxBytes(t *testing.T) {
+	l, err := NewLRUWithOptions(2, WithSizeOf(func(k, v interface{}) int64 {
+		s, _ := v.(string)
+		return int64(len(s))
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "abc")
+	l.Add(2, "de")
+	if n := l.ApproxBytes(); n != 5 {
+		t.Fatalf("bad bytes: %v", n)
+	}
+	l.Add(1, "abcdef")
+	l.UpdateValue(2, "")
+	if n := l.ApproxBytes(); n != 6 {
+		t.Fatalf("bad bytes after overwrite: %v", n)
+	}
+	l.Add(3, "gh") // evicts 2
+	l.Remove(1)
+	if n := l.ApproxBytes(); n != 2 {
+		t.Fatalf("bad bytes after removal: %v", n)
+	}
+	l.Purge()
+	if n := l.ApproxBytes(); n != 0 {
+		t.Fatalf("bad bytes after purge: %v", n)
+	}
+
+	plain, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := plain.ApproxBytes(); n != -1 {
+		t.Errorf("cache without SizeOf should report -1: %v", n)
+	}
+}
+
+// Test that RangeNewest starts with the most recently used key
+func TestLRU_RangeNewest(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.Get(1)
+
+	var keys []interface{}
+	l.RangeNewest(func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []interface{}{1, 3, 2, 0}) {
+		t.Errorf("bad order: %v", keys)
+	}
+
+	keys = nil
+	l.RangeNewest(func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return len(keys) < 2
+	})
+	if len(keys) != 2 {
+		t.Errorf("RangeNewest should stop when f returns false: %v", keys)
+	}
+}
+
+// Test ContainsAll and KeysMatching on partial and empty matches
+func TestLRU_ContainsAllKeysMatching(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+
+	if !l.ContainsAll(0, 2, 3) || !l.ContainsAll() {
+		t.Errorf("all keys should be contained")
+	}
+	if l.ContainsAll(0, 9) {
+		t.Errorf("9 should not be contained")
+	}
+
+	// ContainsAll must not promote 0
+	l.Add(4, 4)
+	if l.Contains(0) {
+		t.Errorf("ContainsAll should not have updated recent-ness of 0")
+	}
+
+	odd := l.KeysMatching(func(k interface{}) bool { return k.(int)%2 == 1 })
+	if !reflect.DeepEqual(odd, []interface{}{1, 3}) {
+		t.Errorf("bad keys: %v", odd)
+	}
+	if none := l.KeysMatching(func(k interface{}) bool { return false }); len(none) != 0 {
+		t.Errorf("bad keys: %v", none)
+	}
+}
+
+// Test that GetOrAddEvicted reports the entry it pushed out
+func TestLRU_GetOrAddEvicted(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "one")
+	l.Add(2, "two")
+	actual, added, k, v, evicted := l.GetOrAddEvicted(2, "other")
+	if actual != "two" || added || k != nil || v != nil || evicted {
+		t.Errorf("2 should be found: %v, %v, %v, %v, %v", actual, added, k, v, evicted)
+	}
+
+	// The hit on 2 left 1 as the oldest key
+	actual, added, k, v, evicted = l.GetOrAddEvicted(3, "three")
+	if actual != "three" || !added || k != 1 || v != "one" || !evicted {
+		t.Errorf("1 should be evicted: %v, %v, %v, %v, %v", actual, added, k, v, evicted)
+	}
+	if l.Contains(1) {
+		t.Errorf("1 should not be contained")
+	}
+}
+
+// Test that Position reports the distance from the most recently used entry
+func TestLRU_Position(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.Get(1)
+
+	for k, want := range map[int]int{1: 0, 3: 1, 2: 2, 0: 3} {
+		if pos, ok := l.Position(k); !ok || pos != want {
+			t.Errorf("bad position for %d: %v, %v", k, pos, ok)
+		}
+	}
+	if _, ok := l.Position(9); ok {
+		t.Errorf("9 should not be contained")
+	}
+
+	// Position must not change the order
+	l.Position(0)
+	l.Add(4, 4)
+	if l.Contains(0) {
+		t.Errorf("Position should not have updated recent-ness of 0")
+	}
+}
+
+// Test that PurgeNoCallback clears the cache without firing onEvict
+func TestLRU_PurgeNoCallback(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRUWithEvict(8, func(k, v interface{}) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	l.Purge()
+	if evictCounter != 5 || l.Len() != 0 {
+		t.Fatalf("Purge should fire onEvict per entry: %v", evictCounter)
+	}
+
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	l.PurgeNoCallback()
+	if evictCounter != 5 || l.Len() != 0 || l.Contains(0) {
+		t.Fatalf("PurgeNoCallback should not fire onEvict: %v", evictCounter)
+	}
+	l.Add(1, 1)
+	if !l.Contains(1) {
+		t.Errorf("cache should be usable after PurgeNoCallback")
+	}
+}
+
+// Test that access counts track Get and Add hits and rank HotKeys
+func TestLRU_AccessCount(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 3; i++ {
+		l.Get(2)
+	}
+	l.Get(0)
+	l.Add(0, 0)
+	l.Get(3)
+	l.Peek(1)
+
+	for k, want := range map[int]uint64{0: 2, 1: 0, 2: 3, 3: 1} {
+		if n, ok := l.AccessCount(k); !ok || n != want {
+			t.Errorf("bad count for %d: %v, %v", k, n, ok)
+		}
+	}
+	if _, ok := l.AccessCount(9); ok {
+		t.Errorf("9 should not be contained")
+	}
+
+	if hot := l.HotKeys(3); !reflect.DeepEqual(hot, []interface{}{2, 0, 3}) {
+		t.Errorf("bad hot keys: %v", hot)
+	}
+	if hot := l.HotKeys(10); len(hot) != 4 {
+		t.Errorf("bad hot keys: %v", hot)
+	}
+	if hot := l.HotKeys(0); len(hot) != 0 {
+		t.Errorf("bad hot keys: %v", hot)
+	}
+}
+
+// Test that a frozen cache grows past its size and trims on Unfreeze
+func TestLRU_Freeze(t *testing.T) {
+	var evicted []interface{}
+	l, err := NewLRUWithEvict(2, func(k, v interface{}) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Freeze()
+	for i := 3; i <= 5; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("no eviction should occur while frozen")
+		}
+	}
+	if l.Len() != 5 || len(evicted) != 0 {
+		t.Fatalf("cache should have grown: %v, %v", l.Len(), evicted)
+	}
+
+	// Explicit removal still works
+	if !l.Remove(3) || len(evicted) != 1 {
+		t.Fatalf("Remove should work while frozen: %v", evicted)
+	}
+
+	if n := l.Unfreeze(); n != 2 {
+		t.Fatalf("bad evicted count: %v", n)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{4, 5}) {
+		t.Errorf("bad keys: %v", l.Keys())
+	}
+	if !reflect.DeepEqual(evicted, []interface{}{3, 1, 2}) {
+		t.Errorf("bad evicted: %v", evicted)
+	}
+	if !l.Add(6, 6) {
+		t.Errorf("eviction should resume after Unfreeze")
+	}
+}
+
+// Test that pinned entries are skipped by eviction
+func TestLRU_Pin(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Pin(1) || l.Pin(9) {
+		t.Fatalf("only present keys can be pinned")
+	}
+	l.Add(3, 3)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Fatalf("2 should have been evicted instead of 1: %v", l.Keys())
+	}
+
+	// With everything pinned the cache grows
+	l.Pin(3)
+	if l.Add(4, 4) {
+		t.Fatalf("no entry should be evictable")
+	}
+	if l.Len() != 3 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	l.Pin(4)
+
+	// Unpinned entries are trimmed by the next add
+	l.Unpin(1)
+	l.Unpin(3)
+	if !l.Add(5, 5) {
+		t.Fatalf("an eviction should have occurred")
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{4, 5}) {
+		t.Errorf("bad keys: %v", l.Keys())
+	}
+
+	// A pinned entry can still be removed explicitly
+	if !l.Remove(4) {
+		t.Errorf("4 should have been removed")
+	}
+}
+
+// Test that ToMap copies the live entries
+func TestLRU_ToMap(t *testing.T) {
+	l, err := NewLRUWithEvict(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, "a")
+	l.Add(2, "b")
+	l.AddWithTTL(3, "c", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	m := l.ToMap()
+	if !reflect.DeepEqual(m, map[interface{}]interface{}{1: "a", 2: "b"}) {
+		t.Fatalf("bad map: %v", m)
+	}
+
+	m[1] = "z"
+	delete(m, 2)
+	if v, _ := l.Peek(1); v != "a" || !l.Contains(2) {
+		t.Errorf("changing the map should not affect the cache")
+	}
+}
+
+// Test that expired keys are absent to Contains, and only ContainsOrExpire
+// removes them
+func TestLRU_ContainsExpired(t *testing.T) {
+	now := time.Unix(1000, 0)
+	evictCounter := 0
+	l, err := NewLRUWithTTL(4, time.Minute, func(k, v interface{}) {
+		evictCounter++
+	}, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	if !l.Contains(1) || !l.ContainsOrExpire(1) {
+		t.Fatalf("1 should be contained")
+	}
+	now = now.Add(time.Minute)
+
+	if l.Contains(1) {
+		t.Errorf("expired 1 should be reported absent")
+	}
+	if l.Len() != 1 || evictCounter != 0 {
+		t.Errorf("Contains should not remove 1: %v, %v", l.Len(), evictCounter)
+	}
+	if l.ContainsOrExpire(1) {
+		t.Errorf("expired 1 should be reported absent")
+	}
+	if l.Len() != 0 || evictCounter != 1 {
+		t.Errorf("ContainsOrExpire should remove 1: %v, %v", l.Len(), evictCounter)
+	}
+}
+
+// Test that GetOrAddWith only calls its factory on a miss
+func TestLRU_GetOrAddWith(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	calls := 0
+	factory := func() interface{} {
+		calls++
+		return calls
+	}
+	if v, added := l.GetOrAddWith(1, factory); v != 1 || !added {
+		t.Errorf("1 should be added: %v, %v", v, added)
+	}
+	if v, added := l.GetOrAddWith(1, factory); v != 1 || added {
+		t.Errorf("1 should be found: %v, %v", v, added)
+	}
+	if calls != 1 {
+		t.Errorf("factory should not be called on a hit: %v", calls)
+	}
+}
+
+// Test that RemoveOldestN removes the coldest entries in order
+func TestLRU_RemoveOldestN(t *testing.T) {
+	evictCounter := 0
+	fill := func() *LRU {
+		l, err := NewLRUWithEvict(4, func(k, v interface{}) {
+			evictCounter++
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := 0; i < 4; i++ {
+			l.Add(i, i)
+		}
+		l.Get(0)
+		return l
+	}
+
+	l := fill()
+	removed := l.RemoveOldestN(2)
+	if !reflect.DeepEqual(removed, []Entry{{Key: 1, Value: 1}, {Key: 2, Value: 2}}) || l.Len() != 2 {
+		t.Errorf("bad removed: %v", removed)
+	}
+	if evictCounter != 2 {
+		t.Errorf("bad evict count: %v", evictCounter)
+	}
+
+	for _, n := range []int{4, 10} {
+		l := fill()
+		if removed := l.RemoveOldestN(n); len(removed) != 4 || l.Len() != 0 {
+			t.Errorf("n=%d: bad removed: %v", n, removed)
+		}
+	}
+	if removed := l.RemoveOldestN(0); len(removed) != 0 || l.Len() != 2 {
+		t.Errorf("nothing should be removed: %v", removed)
+	}
+}
+
+// Test that GetNoPromote fires onAcquire without changing eviction order
+func TestLRU_GetNoPromote(t *testing.T) {
+	acquireCounter := 0
+	onAcquired := func(k interface{}, v interface{}) {
+		acquireCounter++
+	}
+	l, err := NewLRUWithAcquireAndEvict(2, onAcquired, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if v, ok := l.GetNoPromote(1); !ok || v != 1 {
+		t.Fatalf("1 should be found: %v, %v", v, ok)
+	}
+	if _, ok := l.GetNoPromote(9); ok {
+		t.Fatalf("9 should not be found")
+	}
+	if acquireCounter != 3 {
+		t.Errorf("onAcquire should fire on a hit only: %v", acquireCounter)
+	}
+	if s := l.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("bad stats: %+v", s)
+	}
+
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Errorf("GetNoPromote should not have updated recent-ness of 1")
+	}
+}
+
+// Test that a grace factor lets the cache overflow and then trim in a batch
+func TestLRU_OverflowGrace(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRUWithOptions(4,
+		WithOverflowGrace(0.5),
+		WithEvictCallback(func(k, v interface{}) { evictCounter++ }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("no eviction should occur within the grace: %d", i)
+		}
+	}
+	if l.Len() != 6 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if !l.Add(6, 6) {
+		t.Fatalf("an eviction should have occurred")
+	}
+	if l.Len() != 4 || evictCounter != 3 {
+		t.Fatalf("should trim to size: %v, %v", l.Len(), evictCounter)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{3, 4, 5, 6}) {
+		t.Errorf("bad keys: %v", l.Keys())
+	}
+
+	if _, err := NewLRUWithOptions(4, WithOverflowGrace(-1)); err == nil {
+		t.Errorf("negative grace should fail")
+	}
+}
+
+func benchmarkGrace(b *testing.B, opts ...Option) {
+	callbacks := 0
+	l, err := NewLRUWithOptions(1024, append(opts,
+		WithEvictCallback(func(k, v interface{}) { callbacks++ }))...)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	b.ResetTimer()
+
+	batches := 0
+	for i := 0; i < b.N; i++ {
+		if l.Add(i%1100, i) {
+			batches++
+		}
+	}
+	b.ReportMetric(float64(callbacks)/float64(b.N), "evictions/op")
+	b.ReportMetric(float64(batches)/float64(b.N), "evicting-adds/op")
+}
+
+func BenchmarkLRU_NoGrace(b *testing.B) {
+	benchmarkGrace(b)
+}
+
+func BenchmarkLRU_Grace(b *testing.B) {
+	benchmarkGrace(b, WithOverflowGrace(0.1))
+}
+
+// Test that a lowercasing normalizer folds keys together
+func TestLRU_KeyNormalizer(t *testing.T) {
+	lower := func(k interface{}) interface{} {
+		if s, ok := k.(string); ok {
+			return strings.ToLower(s)
+		}
+		return k
+	}
+	var evicted []interface{}
+	l, err := NewLRUWithOptions(2,
+		WithKeyNormalizer(lower),
+		WithEvictCallback(func(k, v interface{}) { evicted = append(evicted, k) }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("Foo", 1)
+	l.Add("FOO", 2)
+	if l.Len() != 1 {
+		t.Fatalf("keys should share an entry: %v", l.Keys())
+	}
+	if v, ok := l.Get("foo"); !ok || v != 2 {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+	if v, ok := l.Peek("fOo"); !ok || v != 2 || !l.Contains("FoO") {
+		t.Errorf("bad value: %v, %v", v, ok)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{"foo"}) {
+		t.Errorf("Keys should report normalized keys: %v", l.Keys())
+	}
+	l.Add(7, 7)
+	if !l.Remove("FOO") || l.Contains("foo") {
+		t.Errorf("Foo should have been removed")
+	}
+	if !reflect.DeepEqual(evicted, []interface{}{"foo"}) {
+		t.Errorf("bad evicted: %v", evicted)
+	}
+}
+
+// Test that AddReturningEvicted returns the pair passed to onEvict
+func TestLRU_AddReturningEvicted(t *testing.T) {
+	var onEvictKey, onEvictValue interface{}
+	l, err := NewLRUWithEvict(2, func(k, v interface{}) {
+		onEvictKey, onEvictValue = k, v
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, "one")
+	if k, v, evicted := l.AddReturningEvicted(2, "two"); k != nil || v != nil || evicted {
+		t.Errorf("nothing should be evicted: %v, %v, %v", k, v, evicted)
+	}
+	k, v, evicted := l.AddReturningEvicted(3, "three")
+	if k != 1 || v != "one" || !evicted {
+		t.Errorf("1 should be evicted: %v, %v, %v", k, v, evicted)
+	}
+	if k != onEvictKey || v != onEvictValue {
+		t.Errorf("onEvict received %v, %v", onEvictKey, onEvictValue)
+	}
+	if k, v, evicted := l.AddReturningEvicted(3, "tres"); k != nil || v != nil || evicted {
+		t.Errorf("updating should not evict: %v, %v, %v", k, v, evicted)
+	}
+}
+
+// Test that a purged cache works normally after holding many entries
+func TestLRU_PurgeLarge(t *testing.T) {
+	l, err := NewLRUWithEvict(1<<14, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1<<14; i++ {
+		l.Add(i, i)
+	}
+	l.Purge()
+	if l.Len() != 0 || len(l.items) != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	if v, ok := l.Get(2); !ok || v != 2 || l.Len() != 4 {
+		t.Fatalf("bad: %v, %v, len %v", v, ok, l.Len())
+	}
+	if k, _, ok := l.GetOldest(); !ok || k != 0 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if !l.Remove(3) || l.Len() != 3 {
+		t.Fatalf("3 should have been removed")
+	}
+}
+
+// heapInUse returns the live heap after a collection
+func heapInUse() int64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapInuse)
+}
+
+// Benchmark the heap held by a large cache before and after it is purged
+func BenchmarkLRU_PurgeReclaim(b *testing.B) {
+	var filled, purged int64
+	for i := 0; i < b.N; i++ {
+		l, err := NewLRUWithEvict(1<<16, nil)
+		if err != nil {
+			b.Fatalf("err: %v", err)
+		}
+		base := heapInUse()
+		for j := 0; j < 1<<16; j++ {
+			l.Add(j, j)
+		}
+		filled += heapInUse() - base
+		l.Purge()
+		purged += heapInUse() - base
+		runtime.KeepAlive(l)
+	}
+	b.ReportMetric(float64(filled)/float64(b.N), "filled-B")
+	b.ReportMetric(float64(purged)/float64(b.N), "purged-B")
+}
+
+// Test that Access promotes a present key with the effects of a single Get
+func TestLRU_Access(t *testing.T) {
+	acquired := 0
+	l, err := NewLRUWithAcquireAndEvict(2, func(k, v interface{}) { acquired++ }, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	acquired = 0
+	if !l.Access(1) {
+		t.Fatalf("1 should be present")
+	}
+	if acquired != 1 {
+		t.Errorf("onAcquire should fire once: %v", acquired)
+	}
+	if s := l.Stats(); s.Hits != 1 || s.Misses != 0 {
+		t.Errorf("bad stats: %+v", s)
+	}
+	if n, _ := l.AccessCount(1); n != 1 {
+		t.Errorf("bad access count: %v", n)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{2, 1}) {
+		t.Errorf("1 should have been promoted: %v", l.Keys())
+	}
+
+	if l.Access(3) {
+		t.Errorf("3 should not be present")
+	}
+	if l.Contains(3) || acquired != 1 || l.Stats().Misses != 1 {
+		t.Errorf("a miss should not add or acquire: %v", acquired)
+	}
+	l.Add(3, 3)
+	if l.Contains(2) || !l.Contains(1) {
+		t.Errorf("2 should have been evicted: %v", l.Keys())
+	}
+}
+
+// Test that a FIFO evicts by insertion order where an LRU evicts by access
+func TestLRU_FIFO(t *testing.T) {
+	victims := func(l *LRU, evicted *[]interface{}) []interface{} {
+		for i := 0; i < 3; i++ {
+			l.Add(i, i)
+		}
+		l.Get(0)
+		l.Add(1, "one")
+		l.Touch(0)
+		l.Add(3, 3)
+		l.Add(4, 4)
+		return *evicted
+	}
+
+	var lruEvicted, fifoEvicted []interface{}
+	lru, err := NewLRUWithEvict(3, func(k, v interface{}) { lruEvicted = append(lruEvicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	fifo, err := NewFIFO(3, func(k, v interface{}) { fifoEvicted = append(fifoEvicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if got := victims(lru, &lruEvicted); !reflect.DeepEqual(got, []interface{}{2, 1}) {
+		t.Errorf("bad LRU victims: %v", got)
+	}
+	if got := victims(fifo, &fifoEvicted); !reflect.DeepEqual(got, []interface{}{0, 1}) {
+		t.Errorf("bad FIFO victims: %v", got)
+	}
+	if v, ok := fifo.Get(2); !ok || v != 2 {
+		t.Errorf("FIFO should still hit: %v, %v", v, ok)
+	}
+	if !reflect.DeepEqual(fifo.Keys(), []interface{}{2, 3, 4}) {
+		t.Errorf("FIFO keys should be in insertion order: %v", fifo.Keys())
+	}
+}
+
+// Test that GetOrLoadMulti loads all missing keys in one call
+func TestLRU_GetOrLoadMulti(t *testing.T) {
+	l, err := NewLRUWithEvict(8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls [][]interface{}
+	loader := func(missing []interface{}) (map[interface{}]interface{}, error) {
+		calls = append(calls, missing)
+		loaded := make(map[interface{}]interface{})
+		for _, k := range missing {
+			if k != 9 {
+				loaded[k] = k.(int) * 10
+			}
+		}
+		return loaded, nil
+	}
+
+	// All miss, except 9 which the loader does not find
+	found, err := l.GetOrLoadMulti([]interface{}{1, 2, 9}, loader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(found, map[interface{}]interface{}{1: 10, 2: 20}) {
+		t.Errorf("bad found: %v", found)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{1, 2}) {
+		t.Errorf("loaded values should be cached: %v", l.Keys())
+	}
+
+	// Mixed
+	l.Add(3, "three")
+	found, err = l.GetOrLoadMulti([]interface{}{3, 4, 1}, loader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(found, map[interface{}]interface{}{1: 10, 3: "three", 4: 40}) {
+		t.Errorf("bad found: %v", found)
+	}
+
+	// All hit
+	if _, err := l.GetOrLoadMulti([]interface{}{1, 2, 3, 4}, loader); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := [][]interface{}{{1, 2, 9}, {4}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("bad loader calls: %v", calls)
+	}
+
+	errLoad := errors.New("load failed")
+	_, err = l.GetOrLoadMulti([]interface{}{1, 5}, func(missing []interface{}) (map[interface{}]interface{}, error) {
+		return map[interface{}]interface{}{5: 50}, errLoad
+	})
+	if err != errLoad || l.Contains(5) {
+		t.Errorf("a failed load should not be cached: %v", err)
+	}
+}
+
+// Test that CheckConsistency holds across a mix of operations
+func TestLRU_CheckConsistency(t *testing.T) {
+	now := time.Now()
+	l, err := NewLRUWithOptions(8,
+		WithClock(func() time.Time { return now }),
+		WithSizeOf(func(k, v interface{}) int64 { return 8 }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	check := func(step string) {
+		t.Helper()
+		if err := l.CheckConsistency(); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+	}
+
+	check("empty")
+	for i := 0; i < 20; i++ {
+		l.Add(i, i)
+	}
+	check("add")
+	l.Get(14)
+	l.Remove(15)
+	l.RemoveOldest()
+	l.AddWithTTL(100, 100, time.Second)
+	l.Pin(16)
+	check("mixed")
+	now = now.Add(time.Minute)
+	l.RemoveExpired()
+	l.Resize(4)
+	check("resize")
+	l.Purge()
+	check("purge")
+
+	// A stray map entry is detected
+	l.Add(1, 1)
+	l.items[2] = l.items[1]
+	if err := l.CheckConsistency(); err == nil {
+		t.Fatalf("should detect a map entry missing from the list")
+	}
+}
+
+// Test that a cache with both limits evicts when either is exceeded
+func TestLRU_Limits(t *testing.T) {
+	var evicted []interface{}
+	l, err := NewLRUWithLimits(3, 10,
+		func(k, v interface{}) int64 { return int64(len(v.(string))) },
+		func(k, v interface{}) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The byte limit is hit first
+	l.Add(1, "aaaa")
+	l.Add(2, "bbbb")
+	l.Add(3, "cccc")
+	if !reflect.DeepEqual(evicted, []interface{}{1}) || l.Len() != 2 || l.Bytes() != 8 {
+		t.Fatalf("bad: evicted %v, len %v, bytes %v", evicted, l.Len(), l.Bytes())
+	}
+	if l.Add(4, "dddddddd") != true || l.Bytes() != 8 || l.Len() != 1 {
+		t.Fatalf("bad: evicted %v, len %v, bytes %v", evicted, l.Len(), l.Bytes())
+	}
+
+	// The count limit is hit first
+	l.Purge()
+	evicted = nil
+	for i := 0; i < 4; i++ {
+		l.Add(i, "x")
+	}
+	if !reflect.DeepEqual(evicted, []interface{}{0}) || l.Len() != 3 || l.Bytes() != 3 {
+		t.Fatalf("bad: evicted %v, len %v, bytes %v", evicted, l.Len(), l.Bytes())
+	}
+
+	// An entry larger than the byte limit does not stay
+	l.Add(9, "much too large")
+	if l.Contains(9) || l.Len() != 0 || l.Bytes() != 0 {
+		t.Fatalf("oversized entry should be evicted: %v", l.Keys())
+	}
+	if err := l.CheckConsistency(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := NewLRUWithLimits(3, 0, func(k, v interface{}) int64 { return 1 }, nil); err == nil {
+		t.Fatalf("should reject a non-positive max bytes")
+	}
+	if _, err := NewLRUWithLimits(3, 10, nil, nil); err == nil {
+		t.Fatalf("should reject a nil size function")
+	}
+}
+
+// Test that StatsAndReset returns the counters of each interval
+func TestLRU_StatsAndReset(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Get(1)
+	l.Get(2)
+	if s := l.StatsAndReset(); s != (Stats{Hits: 1, Misses: 1, Insertions: 1}) {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if s := l.Stats(); s != (Stats{}) {
+		t.Fatalf("stats should be zeroed: %+v", s)
+	}
+	l.Get(1)
+	if s := l.StatsAndReset(); s != (Stats{Hits: 1}) {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
+
+// Test that an unbounded cache never evicts for capacity but keeps its order
+func TestLRU_Unbounded(t *testing.T) {
+	evicted := 0
+	l, err := NewLRUWithOptions(1, WithUnbounded(),
+		WithEvictCallback(func(k, v interface{}) { evicted++ }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("unbounded cache should not evict")
+		}
+	}
+	if l.Len() != 10000 || evicted != 0 || l.Cap() != 0 {
+		t.Fatalf("bad: len %v, evicted %v, cap %v", l.Len(), evicted, l.Cap())
+	}
+
+	l.Get(0)
+	keys := l.Keys()
+	if keys[0] != 1 || keys[len(keys)-1] != 0 {
+		t.Fatalf("order should be maintained: %v ... %v", keys[0], keys[len(keys)-1])
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 1 || evicted != 1 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+
+	if n := l.Resize(100); n != 9899 || l.Len() != 100 {
+		t.Fatalf("Resize should bound the cache: %v evicted, len %v", n, l.Len())
+	}
+
+	// A size of 0 is accepted along with WithUnbounded
+	if l, err := NewLRUWithOptions(0, WithUnbounded()); err != nil || l.Cap() != 0 {
+		t.Fatalf("size 0 should be allowed when unbounded: %v", err)
+	}
+}
+
+// Test that GetOrAddWithCost evicts several entries to fit a costly value
+func TestLRU_GetOrAddWithCost(t *testing.T) {
+	var evicted []interface{}
+	l, err := NewLRUWithCost(10, func(k, v interface{}) int64 { return 2 },
+		func(k, v interface{}) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+
+	// A hit neither adds nor changes the cost
+	if v, added, ev := l.GetOrAddWithCost(0, "other", 9); v != 0 || added || ev {
+		t.Fatalf("0 should be found: %v, %v, %v", v, added, ev)
+	}
+	if l.Cost() != 10 {
+		t.Fatalf("bad cost: %v", l.Cost())
+	}
+
+	// 0 was just used, so 1 through 4 make room for a cost of 7
+	v, added, ev := l.GetOrAddWithCost(5, "five", 7)
+	if v != "five" || !added || !ev {
+		t.Fatalf("5 should be added: %v, %v, %v", v, added, ev)
+	}
+	if !reflect.DeepEqual(evicted, []interface{}{1, 2, 3, 4}) || l.Cost() != 9 {
+		t.Fatalf("bad: evicted %v, cost %v", evicted, l.Cost())
+	}
+	if err := l.CheckConsistency(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// Test that jittered TTLs are spread over the expected range
+func TestLRU_AddMultiWithJitteredTTL(t *testing.T) {
+	now := time.Now()
+	newCache := func() *LRU {
+		l, err := NewLRUWithOptions(128,
+			WithClock(func() time.Time { return now }),
+			WithRandSource(rand.NewSource(42)))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return l
+	}
+	items := make(map[interface{}]interface{})
+	for i := 0; i < 100; i++ {
+		items[i] = i
+	}
+	ttls := func(l *LRU) []time.Duration {
+		var all []time.Duration
+		for i := 0; i < 100; i++ {
+			_, ttl, ok := l.GetWithTTL(i)
+			if !ok {
+				t.Fatalf("%d should be contained", i)
+			}
+			all = append(all, ttl)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+		return all
+	}
+
+	l := newCache()
+	l.AddMultiWithJitteredTTL(items, time.Minute, 10*time.Second)
+	got := ttls(l)
+	if got[0] < 50*time.Second || got[99] > 70*time.Second {
+		t.Fatalf("TTLs out of range: %v to %v", got[0], got[99])
+	}
+	if got[99]-got[0] < 10*time.Second {
+		t.Fatalf("TTLs should be spread out: %v to %v", got[0], got[99])
+	}
+
+	again := newCache()
+	again.AddMultiWithJitteredTTL(items, time.Minute, 10*time.Second)
+	if !reflect.DeepEqual(ttls(again), got) {
+		t.Fatalf("a seeded source should reproduce the TTLs")
+	}
+
+	// Jitter larger than the base TTL still leaves every TTL positive
+	l = newCache()
+	l.AddMultiWithJitteredTTL(items, time.Second, time.Hour)
+	if got := ttls(l); got[0] <= 0 || got[99] >= 2*time.Second {
+		t.Fatalf("TTLs out of range: %v to %v", got[0], got[99])
+	}
+}
+
+// Test that a read-only view exposes only the read methods
+func TestLRU_ReadOnlyView(t *testing.T) {
+	l, err := NewLRUWithEvict(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	view := l.ReadOnlyView()
+
+	if _, ok := view.(LRUCache); ok {
+		t.Fatalf("view should not be usable as an LRUCache")
+	}
+	if _, ok := view.(interface{ Add(k, v interface{}) bool }); ok {
+		t.Fatalf("view should not have Add")
+	}
+	var methods []string
+	typ := reflect.TypeOf(view)
+	for i := 0; i < typ.NumMethod(); i++ {
+		methods = append(methods, typ.Method(i).Name)
+	}
+	want := []string{"Contains", "Get", "Keys", "Len", "Peek", "Values"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Fatalf("bad methods: %v", methods)
+	}
+
+	if v, ok := view.Peek(1); !ok || v != 1 || view.Len() != 2 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if v, ok := view.Get(1); !ok || v != 1 || !view.Contains(2) {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if !reflect.DeepEqual(view.Keys(), []interface{}{2, 1}) ||
+		!reflect.DeepEqual(view.Values(), []interface{}{2, 1}) {
+		t.Fatalf("Get through the view should promote: %v", view.Keys())
+	}
+}
+
+// Test that GetHint only promotes when asked to
+func TestLRU_GetHint(t *testing.T) {
+	var acquired []interface{}
+	l, err := NewLRUWithAcquireAndEvict(3, func(k, v interface{}) {
+		acquired = append(acquired, k)
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Add(i, i)
+	}
+	acquired = nil
+
+	// A scan leaves the order alone
+	for i := 0; i < 3; i++ {
+		if v, ok := l.GetHint(i, false); !ok || v != i {
+			t.Fatalf("bad: %v, %v", v, ok)
+		}
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{0, 1, 2}) {
+		t.Fatalf("order should be preserved: %v", l.Keys())
+	}
+
+	if v, ok := l.GetHint(0, true); !ok || v != 0 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if !reflect.DeepEqual(l.Keys(), []interface{}{1, 2, 0}) {
+		t.Fatalf("0 should have been promoted: %v", l.Keys())
+	}
+	if !reflect.DeepEqual(acquired, []interface{}{0, 1, 2, 0}) {
+		t.Fatalf("onAcquire should fire either way: %v", acquired)
+	}
+	if _, ok := l.GetHint(9, false); ok {
+		t.Fatalf("9 should not be contained")
+	}
+
+	// Neither kind of lookup fires onAcquire once it is disabled for Get
+	l, err = NewLRUWithOptions(3,
+		WithAcquireCallback(func(k, v interface{}) { acquired = append(acquired, k) }),
+		WithFireAcquireOnGet(false))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	acquired = nil
+	l.GetHint(1, true)
+	l.GetHint(1, false)
+	if len(acquired) != 0 {
+		t.Fatalf("onAcquire should not fire: %v", acquired)
+	}
+}
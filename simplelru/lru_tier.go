@@ -0,0 +1,22 @@
+package simplelru
+
+// Tier is a slower backing store chained behind a cache with WithTier.
+type Tier interface {
+	// Set stores a value evicted from the cache for lack of room.
+	Set(key, value interface{})
+	// Get looks up a key that missed in the cache.
+	Get(key interface{}) (value interface{}, ok bool)
+}
+
+// WithTier chains the cache to a secondary tier. Entries evicted to make
+// room are written to the tier, and lookups that miss, such as Get and
+// GetOrLoad, fall through to the tier and add a value found there back into
+// the cache. Such lookups still count as misses in Stats, but do not fire the
+// miss callback. Entries that are removed, purged or expired are not written
+// to the tier.
+func WithTier(tier Tier) Option {
+	return func(c *LRU) error {
+		c.tier = tier
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package simplelru
+
+import "testing"
+
+// mapTier is an in-memory Tier
+type mapTier map[interface{}]interface{}
+
+func (t mapTier) Set(key, value interface{}) { t[key] = value }
+
+func (t mapTier) Get(key interface{}) (interface{}, bool) {
+	v, ok := t[key]
+	return v, ok
+}
+
+// Test that evictions spill into the tier and misses are served from it
+func TestLRU_Tier(t *testing.T) {
+	tier := mapTier{}
+	misses := 0
+	l, err := NewLRUWithOptions(2,
+		WithTier(tier),
+		WithMissCallback(func(k interface{}) { misses++ }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1 into the tier
+	if v, ok := tier[1]; !ok || v != 1 {
+		t.Fatalf("1 should have been written to the tier: %v, %v", v, ok)
+	}
+
+	// A miss falls through and promotes 1, evicting 2 into the tier
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("1 should be served from the tier: %v, %v", v, ok)
+	}
+	if !l.Contains(1) || l.Contains(2) || tier[2] != 2 {
+		t.Errorf("bad keys: %v, tier: %v", l.Keys(), tier)
+	}
+	if misses != 0 {
+		t.Errorf("a tier hit should not fire onMiss: %v", misses)
+	}
+
+	// Explicit removals are not written to the tier
+	l.Remove(3)
+	if _, ok := tier[3]; ok {
+		t.Errorf("3 should not have been written to the tier")
+	}
+	if _, ok := l.Get(3); ok || misses != 1 {
+		t.Errorf("3 should miss in both levels: %v", misses)
+	}
+}
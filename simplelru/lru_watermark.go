@@ -0,0 +1,41 @@
+package simplelru
+
+import "errors"
+
+// WithHighWaterMark sets a callback fired when the fill ratio of the cache,
+// Len divided by Cap, rises to ratio or above, so that callers can react
+// before heavy eviction begins. It fires once per crossing: it is re-armed
+// only once the fill ratio drops back below ratio, for instance through
+// Remove or a larger Resize. Evictions made by Add to fit a new entry do not
+// re-arm it. It never fires for a cache without a size limit.
+func WithHighWaterMark(ratio float64, onHighWater func(len, cap int)) Option {
+	return func(c *LRU) error {
+		if ratio <= 0 || ratio > 1 {
+			return errors.New("Must provide a high water ratio in (0, 1]")
+		}
+		if onHighWater == nil {
+			return errors.New("Must provide a high water callback")
+		}
+		c.highWater = ratio
+		c.onHighWater = onHighWater
+		return nil
+	}
+}
+
+// aboveHighWater reports whether the fill ratio is at or above the high
+// water mark.
+func (c *LRUGeneric[K, V]) aboveHighWater() bool {
+	return c.size > 0 && float64(c.evictList.Len()) >= c.highWater*float64(c.size)
+}
+
+// checkHighWater fires the high water callback if the cache is above the
+// mark and armed was true, and updates whether the mark is tripped.
+func (c *LRUGeneric[K, V]) checkHighWater(armed bool) {
+	if c.onHighWater == nil {
+		return
+	}
+	c.highWaterTripped = c.aboveHighWater()
+	if c.highWaterTripped && armed {
+		c.onHighWater(c.evictList.Len(), c.size)
+	}
+}
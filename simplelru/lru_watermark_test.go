@@ -0,0 +1,66 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that the high water callback fires once per upward crossing
+func TestLRU_HighWaterMark(t *testing.T) {
+	var fired [][2]int
+	l, err := NewLRUWithOptions(10, WithHighWaterMark(0.8, func(n, capacity int) {
+		fired = append(fired, [2]int{n, capacity})
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 7; i++ {
+		l.Add(i, i)
+	}
+	if len(fired) != 0 {
+		t.Fatalf("should not fire below the mark: %v", fired)
+	}
+	l.Add(7, 7)
+	if !reflect.DeepEqual(fired, [][2]int{{8, 10}}) {
+		t.Fatalf("should fire when crossing the mark: %v", fired)
+	}
+
+	// Staying above the mark, even while evicting, does not fire again
+	for i := 8; i < 30; i++ {
+		l.Add(i, i)
+	}
+	if len(fired) != 1 {
+		t.Fatalf("should fire once per crossing: %v", fired)
+	}
+
+	// Dropping below re-arms it
+	l.Remove(29)
+	l.Remove(28)
+	l.Remove(27)
+	if len(fired) != 1 {
+		t.Fatalf("should not fire below the mark: %v", fired)
+	}
+	l.Add(27, 27)
+	if !reflect.DeepEqual(fired, [][2]int{{8, 10}, {8, 10}}) {
+		t.Fatalf("should fire again after re-arming: %v", fired)
+	}
+
+	// Growing re-arms it, and shrinking crosses it
+	l.Resize(20)
+	l.Resize(9)
+	if !reflect.DeepEqual(fired[2:], [][2]int{{8, 9}}) {
+		t.Fatalf("shrinking should cross the mark: %v", fired)
+	}
+	l.Purge()
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	if len(fired) != 4 {
+		t.Fatalf("Purge should re-arm the mark: %v", fired)
+	}
+
+	if _, err := NewLRUWithOptions(10, WithHighWaterMark(1.5, func(int, int) {})); err == nil {
+		t.Fatalf("should reject a ratio above 1")
+	}
+}
@@ -0,0 +1,154 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// errGoexit is the error of a flightGroup call whose function called
+// runtime.Goexit, as t.FailNow does.
+var errGoexit = errors.New("lru: loader called runtime.Goexit")
+
+// flightPanic is the value a flightGroup call panics with when its function
+// panicked, carrying the stack of the goroutine that ran it.
+type flightPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *flightPanic) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+// flightCall is an in-flight or completed flightGroup.Do call.
+type flightCall struct {
+	done    chan struct{} // closed once the call completes
+	value   interface{}
+	err     error
+	panic   *flightPanic       // non-nil if the function panicked
+	dups    int                // callers that joined the call after the first
+	waiters int                // callers still waiting for the result
+	cancel  context.CancelFunc // cancels the context of a DoContext call
+}
+
+// result returns the outcome of a completed call, panicking again if its
+// function panicked.
+func (c *flightCall) result() (interface{}, error) {
+	if c.panic != nil {
+		panic(c.panic)
+	}
+	return c.value, c.err
+}
+
+// flightGroup deduplicates concurrent calls for the same key, in the manner
+// of golang.org/x/sync/singleflight. The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[interface{}]*flightCall
+}
+
+// Do runs fn for key, unless a call for key is already in flight, in which
+// case it waits for that call and returns its result instead. If fn panics,
+// Do panics in every caller, with a value holding the original panic and
+// its stack.
+func (g *flightGroup) Do(
+	key interface{},
+	fn func() (interface{}, error),
+) (value interface{}, err error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		c.waiters++
+		g.mu.Unlock()
+		<-c.done
+		return c.result()
+	}
+	c := &flightCall{done: make(chan struct{}), waiters: 1}
+	if g.calls == nil {
+		g.calls = make(map[interface{}]*flightCall)
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.doCall(c, key, fn)
+	return c.result()
+}
+
+// DoContext is like Do, but passes fn a context that carries the values of
+// ctx without its deadline or cancellation, so that the caller that started
+// the call cannot fail the others by giving up. Each caller instead stops
+// waiting with ctx.Err() once its own ctx is done, and the context of fn is
+// cancelled only after every caller has stopped waiting. fn runs in its own
+// goroutine; if it panics, the callers still waiting panic.
+func (g *flightGroup) DoContext(
+	ctx context.Context,
+	key interface{},
+	fn func(ctx context.Context) (interface{}, error),
+) (value interface{}, err error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+		c.waiters++
+	} else {
+		c = &flightCall{done: make(chan struct{}), waiters: 1}
+		var fnCtx context.Context
+		fnCtx, c.cancel = context.WithCancel(detachedContext{ctx})
+		if g.calls == nil {
+			g.calls = make(map[interface{}]*flightCall)
+		}
+		g.calls[key] = c
+		go func() {
+			defer c.cancel()
+			g.doCall(c, key, func() (interface{}, error) { return fn(fnCtx) })
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result()
+	case <-ctx.Done():
+		g.mu.Lock()
+		if c.waiters--; c.waiters == 0 && c.cancel != nil {
+			c.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext carries the values of a context, but neither its deadline
+// nor its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// doCall runs fn for c, then unregisters the call and releases its waiters,
+// even if fn panics or calls runtime.Goexit.
+func (g *flightGroup) doCall(c *flightCall, key interface{}, fn func() (interface{}, error)) {
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			if r := recover(); r != nil {
+				c.panic = &flightPanic{value: r, stack: debug.Stack()}
+			} else {
+				c.err = errGoexit
+			}
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = fn()
+	normalReturn = true
+}
@@ -0,0 +1,46 @@
+package lru
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Warmup loads keys into c using at most concurrency concurrent calls of
+// loader, adding each successfully loaded value. Values are added as they
+// are loaded, so once keys outnumber the capacity of c later loads may evict
+// earlier ones. The errors of the failed loads are joined, in the order of
+// keys, into the returned error.
+func Warmup(
+	c *Cache,
+	keys []interface{},
+	loader func(key interface{}) (interface{}, error),
+	concurrency int,
+) error {
+	if concurrency <= 0 {
+		return errors.New("Must provide a positive concurrency")
+	}
+	errs := make([]error, len(keys))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(keys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				value, err := loader(keys[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("warming %v: %w", keys[i], err)
+					continue
+				}
+				c.Add(keys[i], value)
+			}
+		}()
+	}
+	for i := range keys {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errors.Join(errs...)
+}
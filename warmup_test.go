@@ -0,0 +1,64 @@
+package lru
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// test that Warmup loads every key without exceeding its concurrency
+func TestWarmup(t *testing.T) {
+	l, err := New(16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var running, peak int32
+	errOdd := errors.New("odd key")
+	loader := func(k interface{}) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if k.(int)%5 == 1 {
+			return nil, errOdd
+		}
+		return k.(int) * 2, nil
+	}
+
+	keys := make([]interface{}, 20)
+	for i := range keys {
+		keys[i] = i
+	}
+	err = Warmup(l, keys, loader, 3)
+	if !errors.Is(err, errOdd) {
+		t.Fatalf("errors should be aggregated: %v", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 4 {
+		t.Fatalf("bad error count: %v", n)
+	}
+	if peak > 3 {
+		t.Fatalf("concurrency cap exceeded: %v", peak)
+	}
+	if l.Len() != 16 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	for i := 0; i < 20; i++ {
+		if v, ok := l.Peek(i); ok && v != i*2 {
+			t.Fatalf("bad value for %d: %v", i, v)
+		}
+		if i%5 == 1 && l.Contains(i) {
+			t.Fatalf("failed load %d should not be cached", i)
+		}
+	}
+
+	if err := Warmup(l, keys, loader, 0); err == nil {
+		t.Fatalf("should reject a non-positive concurrency")
+	}
+}